@@ -3,8 +3,10 @@ package context
 import (
 	"fmt"
 	configClient "github.com/ahmetson/config-lib/client"
+	"github.com/ahmetson/dev-lib/dep_manager"
 	"github.com/ahmetson/os-lib/path"
 	"path/filepath"
+	"time"
 )
 
 // Specifically for Dev Context
@@ -13,8 +15,48 @@ const (
 	SrcKey = "SERVICE_DEPS_SRC"
 	// BinKey is the path of bin directory from the configuration
 	BinKey = "SERVICE_DEPS_BIN"
+	// StateKey is the path of the directory with the records of the running dependencies
+	StateKey = "SERVICE_DEPS_STATE"
+	// LogsKey is the path of the directory with the output of the running dependencies
+	LogsKey = "SERVICE_DEPS_LOGS"
+	// LockKey is the path of the lockfile with the versions of the installed dependencies
+	LockKey = "SERVICE_DEPS_LOCK"
+	// LogMaxSizeKey is the size of the dependency log in bytes, after which it's rotated
+	LogMaxSizeKey = "SERVICE_DEPS_LOG_MAX_SIZE"
+	// LogMaxFilesKey is the amount of the rotated logs kept for each dependency
+	LogMaxFilesKey = "SERVICE_DEPS_LOG_MAX_FILES"
+	// KeepBinsKey is the amount of the built binaries kept for each dependency to roll back
+	KeepBinsKey = "SERVICE_DEPS_KEEP_BINS"
+	// CloneTimeoutKey is the limit of the source code download in seconds. 0 means no limit
+	CloneTimeoutKey = "SERVICE_DEPS_CLONE_TIMEOUT"
+	// BuildTimeoutKey is the limit of the dependency build in seconds. 0 means no limit
+	BuildTimeoutKey = "SERVICE_DEPS_BUILD_TIMEOUT"
+	// CloseGraceKey is the time in seconds to wait for the dependency to exit after the close command
+	CloseGraceKey = "SERVICE_DEPS_CLOSE_GRACE"
+	// TermGraceKey is the time in seconds to wait for the dependency to exit after the termination signal
+	TermGraceKey = "SERVICE_DEPS_TERM_GRACE"
+	// HealthIntervalKey is the interval in seconds between the heartbeats of the running dependencies. 0 disables the checks
+	HealthIntervalKey = "SERVICE_DEPS_HEALTH_INTERVAL"
 )
 
+// The default limits of the dependency installation and stop in seconds
+const (
+	defaultCloneTimeout   uint64 = 300
+	defaultBuildTimeout   uint64 = 600
+	defaultCloseGrace     uint64 = 5
+	defaultTermGrace      uint64 = 5
+	defaultHealthInterval        = uint64(dep_manager.DefaultHealthInterval / time.Second)
+)
+
+// The default rotation of the dependency logs
+const (
+	defaultLogMaxSize  = uint64(dep_manager.DefaultLogMaxSize)
+	defaultLogMaxFiles = uint64(dep_manager.DefaultLogMaxFiles)
+)
+
+// defaultKeepBins is the amount of the built binaries kept for each dependency
+const defaultKeepBins = uint64(dep_manager.DefaultKeepBins)
+
 // SetDevDefaults sets the required developer context's parameters in the configuration engine.
 //
 // It sets the source manager's bin path and source path in (dot is current dir by executable):
@@ -22,6 +64,9 @@ const (
 //		/bin.exe
 //		/_sds/source/
 //		/_sds/bin/
+//		/_sds/state/
+//		/_sds/logs/
+//		/_sds/deps.lock
 //	 /_sds/source/github.com.ahmetson.proxy-lib/main.go
 //	 /_sds/bin/github.com.ahmetson.proxy-lib.exe
 func SetDevDefaults(engine configClient.Interface) error {
@@ -32,6 +77,9 @@ func SetDevDefaults(engine configClient.Interface) error {
 
 	srcPath := filepath.Join(currentDir, "_sds", "src")
 	binPath := filepath.Join(currentDir, "_sds", "bin")
+	statePath := filepath.Join(currentDir, "_sds", "state")
+	logsPath := filepath.Join(currentDir, "_sds", "logs")
+	lockPath := filepath.Join(currentDir, "_sds", dep_manager.LockFileName)
 
 	if err := engine.SetDefault(SrcKey, srcPath); err != nil {
 		return fmt.Errorf("configClient.SetDefault('%s', '%s'): %w", SrcKey, srcPath, err)
@@ -39,6 +87,39 @@ func SetDevDefaults(engine configClient.Interface) error {
 	if err := engine.SetDefault(BinKey, binPath); err != nil {
 		return fmt.Errorf("configClient.SetDefault('%s', '%s'): %w", BinKey, binPath, err)
 	}
+	if err := engine.SetDefault(StateKey, statePath); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', '%s'): %w", StateKey, statePath, err)
+	}
+	if err := engine.SetDefault(LogsKey, logsPath); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', '%s'): %w", LogsKey, logsPath, err)
+	}
+	if err := engine.SetDefault(LockKey, lockPath); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', '%s'): %w", LockKey, lockPath, err)
+	}
+	if err := engine.SetDefault(LogMaxSizeKey, defaultLogMaxSize); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", LogMaxSizeKey, defaultLogMaxSize, err)
+	}
+	if err := engine.SetDefault(LogMaxFilesKey, defaultLogMaxFiles); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", LogMaxFilesKey, defaultLogMaxFiles, err)
+	}
+	if err := engine.SetDefault(KeepBinsKey, defaultKeepBins); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", KeepBinsKey, defaultKeepBins, err)
+	}
+	if err := engine.SetDefault(CloneTimeoutKey, defaultCloneTimeout); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", CloneTimeoutKey, defaultCloneTimeout, err)
+	}
+	if err := engine.SetDefault(BuildTimeoutKey, defaultBuildTimeout); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", BuildTimeoutKey, defaultBuildTimeout, err)
+	}
+	if err := engine.SetDefault(HealthIntervalKey, defaultHealthInterval); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", HealthIntervalKey, defaultHealthInterval, err)
+	}
+	if err := engine.SetDefault(CloseGraceKey, defaultCloseGrace); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", CloseGraceKey, defaultCloseGrace, err)
+	}
+	if err := engine.SetDefault(TermGraceKey, defaultTermGrace); err != nil {
+		return fmt.Errorf("configClient.SetDefault('%s', %d): %w", TermGraceKey, defaultTermGrace, err)
+	}
 
 	return nil
 }
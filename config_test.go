@@ -19,7 +19,7 @@ type TestConfigSuite struct {
 func (suite *TestConfigSuite) SetupTest() {}
 
 func (suite *TestConfigSuite) TestConstants() {
-	fmt.Printf("Configuration keys: source path: %s, bin path: %s\n", SrcKey, BinKey)
+	fmt.Printf("Configuration keys: source path: %s, bin path: %s, state path: %s\n", SrcKey, BinKey, StateKey)
 }
 
 // In order for 'go test' to run this suite, we need to create
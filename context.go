@@ -29,6 +29,8 @@ type Interface interface {
 
 // A New orchestra. Optionally pass the type of the context.
 // Or the context type could be retrieved from the config.ContextFlag.
+//
+// The context type must be registered by Register.
 func New(ctxTypes ...ContextType) (Interface, error) {
 	ctxType := DevContext // default is used a dev context
 
@@ -38,9 +40,15 @@ func New(ctxTypes ...ContextType) (Interface, error) {
 		ctxType = arg.FlagValue(ContextFlag)
 	}
 
-	if ctxType == DevContext {
-		return NewDev()
+	newCtx, err := factory(ctxType)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("only %s supported, not %s", DevContext, ctxType)
+	ctx, err := newCtx()
+	if err != nil {
+		return nil, fmt.Errorf("new '%s' context: %w", ctxType, err)
+	}
+
+	return ctx, nil
 }
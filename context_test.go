@@ -22,7 +22,7 @@ func (test *TestCtxSuite) Test_0_New() {
 
 	// Before testing, we make sure that the files don't exist
 	_, err := New(UnknownContext)
-	s.Require().Error(err, "only dev context supported")
+	s.Require().Error(err, "unknown context type is not registered")
 
 	ctx, err := New(DevContext)
 	s.Require().NoError(err)
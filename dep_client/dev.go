@@ -7,6 +7,8 @@ import (
 	"github.com/ahmetson/datatype-lib/data_type/key_value"
 	"github.com/ahmetson/datatype-lib/message"
 	"github.com/ahmetson/dev-lib/dep_handler"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"github.com/ahmetson/dev-lib/source"
 	handlerConfig "github.com/ahmetson/handler-lib/config"
 	"time"
 )
@@ -23,9 +25,36 @@ type Interface interface {
 	CloseDep(depClient *clientConfig.Client) error
 	Uninstall(url string, localSrc string, localBin string) error
 	Run(url string, id string, parent *clientConfig.Client, localBin string) error
-	Install(url string, localSrc string) error
+	RunDetached(url string, id string, parent *clientConfig.Client, depClient *clientConfig.Client, localBin string) error
+	Install(url string, localSrc string, optionalRef ...*source.Ref) error
 	Running(depClient *clientConfig.Client) (bool, error)
+	Probe(depClient *clientConfig.Client) (*dep_manager.ProbeResult, error)
 	Installed(url string, localBin string) (bool, error)
+	SetRestartPolicy(id string, policy *dep_manager.RestartPolicy) error
+	SetBuildMode(url string, mode dep_manager.BuildMode) error
+	RestartStatus(id string) (*dep_manager.RestartStatus, error)
+	SetGroup(group *dep_manager.Group) error
+	RemoveGroup(id string) error
+	SetGraph(graph *dep_manager.Graph) error
+	Graph() (*dep_manager.ResolvedGraph, error)
+	RunGraph(deps []*dep_handler.GraphDep, parent *clientConfig.Client) error
+	StopGraph() error
+	InstallAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error)
+	UpdateAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error)
+	RunAsync(url string, id string, parent *clientConfig.Client, localBin string) (string, error)
+	JobStatus(jobId string) (*dep_handler.Job, error)
+	CancelJob(jobId string) error
+	Stop(id string) (*dep_manager.ExitStatus, error)
+	List() ([]*dep_manager.DepInfo, error)
+	Info(id string) (*dep_manager.DepInfo, error)
+	Logs(id string, lines int) ([]string, error)
+	LogsFrom(id string, offset int64) ([]string, int64, error)
+	CrashReports(id string) ([]*dep_manager.CrashReport, error)
+	WaitReady(id string, depClient *clientConfig.Client, timeout time.Duration) error
+	HealthStatus(id string) (*dep_manager.HealthStatus, error)
+	Update(url string, localSrc string, optionalRef ...*source.Ref) error
+	Rollback(url string) (*dep_manager.BinVersion, error)
+	UpdateLock(url string) ([]*dep_manager.LockEntry, error)
 }
 
 func New() (*Client, error) {
@@ -74,11 +103,11 @@ func (c *Client) CloseDep(depClient *clientConfig.Client) error {
 
 	reply, err := c.socket.Request(&req)
 	if err != nil {
-		return fmt.Errorf("socket.Submit('%s'): %w", dep_handler.CloseDep, err)
+		return requestError(dep_handler.CloseDep, err)
 	}
 
 	if !reply.IsOK() {
-		return fmt.Errorf("c.socket.Requeset(request='%v'): reply failed with: %s", req, reply.ErrorMessage())
+		return replyError(reply)
 	}
 
 	return nil
@@ -120,18 +149,50 @@ func (c *Client) Run(url string, id string, parent *clientConfig.Client, localBi
 
 	reply, err := c.socket.Request(&req)
 	if err != nil {
-		return fmt.Errorf("socket.Submit('%s'): %w", dep_handler.RunDep, err)
+		return requestError(dep_handler.RunDep, err)
 	}
 
 	if !reply.IsOK() {
-		return fmt.Errorf("reply.Message: %s", reply.ErrorMessage())
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// RunDetached runs the dependency that keeps running after this service exits.
+// The depClient is the socket parameters of the dependency.
+// It's optional, but without it, the re-attached dependency can not be checked by its socket.
+func (c *Client) RunDetached(url string, id string, parent *clientConfig.Client, depClient *clientConfig.Client, localBin string) error {
+	req := message.Request{
+		Command: dep_handler.RunDep,
+		Parameters: key_value.New().
+			Set("parent", parent).
+			Set("url", url).
+			Set("id", id).
+			Set("detach", true),
+	}
+	if depClient != nil {
+		req.Parameters.Set("manager", depClient)
+	}
+	if len(localBin) > 0 {
+		req.Parameters.Set("local_bin", localBin)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.RunDep, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
 	}
 
 	return nil
 }
 
 // Install the dependency from the source code. It compiles it.
-func (c *Client) Install(url, localSrc string) error {
+// Optionally, pass the branch, tag or commit to check out.
+func (c *Client) Install(url, localSrc string, optionalRef ...*source.Ref) error {
 	req := message.Request{
 		Command:    dep_handler.InstallDep,
 		Parameters: key_value.New().Set("url", url),
@@ -139,19 +200,79 @@ func (c *Client) Install(url, localSrc string) error {
 	if len(localSrc) > 0 {
 		req.Parameters.Set("local_src", localSrc)
 	}
+	if err := setRef(req.Parameters, optionalRef); err != nil {
+		return fmt.Errorf("setRef: %w", err)
+	}
 
 	reply, err := c.socket.Request(&req)
 	if err != nil {
-		return fmt.Errorf("socket.Submit('%s'): %w", dep_handler.InstallDep, err)
+		return requestError(dep_handler.InstallDep, err)
 	}
 
 	if !reply.IsOK() {
-		return fmt.Errorf("reply.Message: %s", reply.ErrorMessage())
+		return replyError(reply)
 	}
 
 	return nil
 }
 
+// Update fetches the latest commit of the installed dependency, and rebuilds it.
+// If the build fails, then the previous binary is restored.
+// Optionally, pass the branch, tag or commit to track.
+func (c *Client) Update(url, localSrc string, optionalRef ...*source.Ref) error {
+	req := message.Request{
+		Command:    dep_handler.UpdateDep,
+		Parameters: key_value.New().Set("url", url),
+	}
+	if len(localSrc) > 0 {
+		req.Parameters.Set("local_src", localSrc)
+	}
+	if err := setRef(req.Parameters, optionalRef); err != nil {
+		return fmt.Errorf("setRef: %w", err)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.UpdateDep, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// Rollback installs the binary of the dependency built before the installed one.
+// Each call goes one version back. Returns the installed version.
+func (c *Client) Rollback(url string) (*dep_manager.BinVersion, error) {
+	req := message.Request{
+		Command:    dep_handler.RollbackDep,
+		Parameters: key_value.New().Set("url", url),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.RollbackDep, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("version")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('version'): %w", err)
+	}
+
+	var version dep_manager.BinVersion
+	if err := kv.Interface(&version); err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &version, nil
+}
+
 // Running checks is the service running or not
 func (c *Client) Running(depClient *clientConfig.Client) (bool, error) {
 	req := message.Request{
@@ -162,11 +283,11 @@ func (c *Client) Running(depClient *clientConfig.Client) (bool, error) {
 
 	reply, err := c.socket.Request(&req)
 	if err != nil {
-		return false, fmt.Errorf("socket.Request('%s'): %w", dep_handler.DepRunning, err)
+		return false, requestError(dep_handler.DepRunning, err)
 	}
 
 	if !reply.IsOK() {
-		return false, fmt.Errorf("reply.Message: %s", reply.ErrorMessage())
+		return false, replyError(reply)
 	}
 
 	res, err := reply.ReplyParameters().BoolValue("running")
@@ -177,6 +298,38 @@ func (c *Client) Running(depClient *clientConfig.Client) (bool, error) {
 	return res, nil
 }
 
+// Probe sends the heartbeat to the dependency.
+// Returns the latency, the failure reason if the dependency is not reachable, and the metadata of the dependency.
+func (c *Client) Probe(depClient *clientConfig.Client) (*dep_manager.ProbeResult, error) {
+	req := message.Request{
+		Command: dep_handler.ProbeDep,
+		Parameters: key_value.New().
+			Set("dep", depClient),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.ProbeDep, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("probe")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('probe'): %w", err)
+	}
+
+	var result dep_manager.ProbeResult
+	err = kv.Interface(&result)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &result, nil
+}
+
 // Installed checks is the service installed
 func (c *Client) Installed(url, localBin string) (bool, error) {
 	req := message.Request{
@@ -189,11 +342,11 @@ func (c *Client) Installed(url, localBin string) (bool, error) {
 
 	reply, err := c.socket.Request(&req)
 	if err != nil {
-		return false, fmt.Errorf("socket.Request('%s'): %w", dep_handler.DepInstalled, err)
+		return false, requestError(dep_handler.DepInstalled, err)
 	}
 
 	if !reply.IsOK() {
-		return false, fmt.Errorf("reply.Message: %s", reply.ErrorMessage())
+		return false, replyError(reply)
 	}
 
 	res, err := reply.ReplyParameters().BoolValue("installed")
@@ -203,3 +356,678 @@ func (c *Client) Installed(url, localBin string) (bool, error) {
 
 	return res, nil
 }
+
+// SetRestartPolicy sets the policy to restart the dependency by its id when it exits.
+// Pass nil policy to remove it.
+func (c *Client) SetRestartPolicy(id string, policy *dep_manager.RestartPolicy) error {
+	req := message.Request{
+		Command:    dep_handler.SetRestartPolicy,
+		Parameters: key_value.New().Set("id", id),
+	}
+	if policy != nil {
+		req.Parameters.Set("policy", policy)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.SetRestartPolicy, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// SetBuildMode sets how the modules of the dependency by its url are treated during the build.
+// Pass the empty mode to use the default one.
+func (c *Client) SetBuildMode(url string, mode dep_manager.BuildMode) error {
+	req := message.Request{
+		Command:    dep_handler.SetBuildMode,
+		Parameters: key_value.New().Set("url", url),
+	}
+	if len(mode) > 0 {
+		req.Parameters.Set("mode", mode)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.SetBuildMode, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// RestartStatus returns the restarts of the dependency by its id
+func (c *Client) RestartStatus(id string) (*dep_manager.RestartStatus, error) {
+	req := message.Request{
+		Command:    dep_handler.RestartStatus,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.RestartStatus, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("status")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('status'): %w", err)
+	}
+
+	var status dep_manager.RestartStatus
+	err = kv.Interface(&status)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &status, nil
+}
+
+// SetGroup adds the group of the dependencies that are restarted together by the group strategy.
+func (c *Client) SetGroup(group *dep_manager.Group) error {
+	if group == nil {
+		return fmt.Errorf("nil group")
+	}
+
+	req := message.Request{
+		Command:    dep_handler.SetDepGroup,
+		Parameters: key_value.New().Set("group", group),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.SetDepGroup, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// RemoveGroup deletes the group by its id. The members keep running.
+func (c *Client) RemoveGroup(id string) error {
+	req := message.Request{
+		Command:    dep_handler.RemoveDepGroup,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.RemoveDepGroup, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// SetGraph sets the dependencies between the dependencies by their ids, used by RunGraph and StopGraph.
+func (c *Client) SetGraph(graph *dep_manager.Graph) error {
+	if graph == nil {
+		return fmt.Errorf("nil graph")
+	}
+
+	req := message.Request{
+		Command:    dep_handler.SetDepGraph,
+		Parameters: key_value.New().Set("graph", graph),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.SetDepGraph, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// Graph returns the start order of the graph set by SetGraph.
+// Returns nil if there is no graph.
+func (c *Client) Graph() (*dep_manager.ResolvedGraph, error) {
+	req := message.Request{
+		Command:    dep_handler.DepGraph,
+		Parameters: key_value.New(),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.DepGraph, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	if !reply.ReplyParameters().Exist("graph") {
+		return nil, nil
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("graph")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('graph'): %w", err)
+	}
+
+	var resolved dep_manager.ResolvedGraph
+	err = kv.Interface(&resolved)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &resolved, nil
+}
+
+// RunGraph runs the dependencies in the order of the graph set by SetGraph.
+// The independent dependencies start in parallel.
+// If any dependency fails, then the started ones are stopped.
+//
+// The client timeout must be longer than the start of all dependencies.
+func (c *Client) RunGraph(deps []*dep_handler.GraphDep, parent *clientConfig.Client) error {
+	req := message.Request{
+		Command: dep_handler.RunDepGraph,
+		Parameters: key_value.New().
+			Set("parent", parent).
+			Set("deps", deps),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.RunDepGraph, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// StopGraph stops the running dependencies of the graph in the reverse start order.
+func (c *Client) StopGraph() error {
+	req := message.Request{
+		Command:    dep_handler.StopDepGraph,
+		Parameters: key_value.New(),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.StopDepGraph, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// WaitReady waits until the running dependency replies to the heartbeat.
+// If the depClient is nil, then the socket the dependency was run with is used.
+// Returns dep_manager.ErrExited if the dependency exited before being ready.
+//
+// The client timeout must be longer than the given timeout.
+func (c *Client) WaitReady(id string, depClient *clientConfig.Client, timeout time.Duration) error {
+	req := message.Request{
+		Command: dep_handler.WaitReady,
+		Parameters: key_value.New().
+			Set("id", id).
+			Set("timeout", uint64(timeout.Milliseconds())),
+	}
+	if depClient != nil {
+		req.Parameters.Set("dep", depClient)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.WaitReady, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
+
+// HealthStatus returns the health of the running dependency by the health monitor of the dep manager
+func (c *Client) HealthStatus(id string) (*dep_manager.HealthStatus, error) {
+	req := message.Request{
+		Command:    dep_handler.HealthStatus,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.HealthStatus, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("status")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('status'): %w", err)
+	}
+
+	var status dep_manager.HealthStatus
+	err = kv.Interface(&status)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &status, nil
+}
+
+// Stop the dependency spawned by the dep manager by its id.
+// Returns the exit status of the dependency.
+func (c *Client) Stop(id string) (*dep_manager.ExitStatus, error) {
+	req := message.Request{
+		Command:    dep_handler.StopDep,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.StopDep, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("exit")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('exit'): %w", err)
+	}
+
+	var status dep_manager.ExitStatus
+	err = kv.Interface(&status)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &status, nil
+}
+
+// List returns the dependencies running by the dep manager
+func (c *Client) List() ([]*dep_manager.DepInfo, error) {
+	req := message.Request{
+		Command:    dep_handler.ListDeps,
+		Parameters: key_value.New(),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.ListDeps, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kvs, err := reply.ReplyParameters().NestedListValue("deps")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedListValue('deps'): %w", err)
+	}
+
+	infos := make([]*dep_manager.DepInfo, len(kvs))
+	for i, kv := range kvs {
+		var info dep_manager.DepInfo
+		if err := kv.Interface(&info); err != nil {
+			return nil, fmt.Errorf("kvs[%d].Interface: %w", i, err)
+		}
+		infos[i] = &info
+	}
+
+	return infos, nil
+}
+
+// Info returns the dependency running by the dep manager
+func (c *Client) Info(id string) (*dep_manager.DepInfo, error) {
+	req := message.Request{
+		Command:    dep_handler.DepInfo,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.DepInfo, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("dep")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('dep'): %w", err)
+	}
+
+	var info dep_manager.DepInfo
+	err = kv.Interface(&info)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &info, nil
+}
+
+// Logs returns the last lines of the dependency output.
+// The output of the exited dependency is kept, so the crashed dependency could be inspected.
+func (c *Client) Logs(id string, lines int) ([]string, error) {
+	req := message.Request{
+		Command: dep_handler.DepLogs,
+		Parameters: key_value.New().
+			Set("id", id).
+			Set("lines", uint64(lines)),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.DepLogs, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	output, err := reply.ReplyParameters().StringsValue("lines")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.StringsValue('lines'): %w", err)
+	}
+
+	return output, nil
+}
+
+// LogsFrom returns the lines of the dependency output written after the offset,
+// and the offset to read the next lines from. Start with 0 offset. See FollowLogs.
+func (c *Client) LogsFrom(id string, offset int64) ([]string, int64, error) {
+	req := message.Request{
+		Command: dep_handler.DepLogs,
+		Parameters: key_value.New().
+			Set("id", id).
+			Set("offset", uint64(offset)),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, 0, requestError(dep_handler.DepLogs, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, 0, replyError(reply)
+	}
+
+	output, err := reply.ReplyParameters().StringsValue("lines")
+	if err != nil {
+		return nil, 0, fmt.Errorf("reply.Parameters.StringsValue('lines'): %w", err)
+	}
+	next, err := reply.ReplyParameters().Uint64Value("offset")
+	if err != nil {
+		return nil, 0, fmt.Errorf("reply.Parameters.Uint64Value('offset'): %w", err)
+	}
+
+	return output, int64(next), nil
+}
+
+// UpdateLock sets the locked commit of the dependency to the head of its branch.
+// If the url is empty, then all locked dependencies are updated.
+// The next Install builds the updated commit.
+func (c *Client) UpdateLock(url string) ([]*dep_manager.LockEntry, error) {
+	req := message.Request{
+		Command:    dep_handler.UpdateLock,
+		Parameters: key_value.New(),
+	}
+	if len(url) > 0 {
+		req.Parameters.Set("url", url)
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.UpdateLock, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kvs, err := reply.ReplyParameters().NestedListValue("entries")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedListValue('entries'): %w", err)
+	}
+
+	entries := make([]*dep_manager.LockEntry, len(kvs))
+	for i, kv := range kvs {
+		var entry dep_manager.LockEntry
+		if err := kv.Interface(&entry); err != nil {
+			return nil, fmt.Errorf("kvs[%d].Interface: %w", i, err)
+		}
+		entries[i] = &entry
+	}
+
+	return entries, nil
+}
+
+// CrashReports returns the last exits of the dependency, the oldest first
+func (c *Client) CrashReports(id string) ([]*dep_manager.CrashReport, error) {
+	req := message.Request{
+		Command:    dep_handler.CrashReports,
+		Parameters: key_value.New().Set("id", id),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.CrashReports, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kvs, err := reply.ReplyParameters().NestedListValue("reports")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedListValue('reports'): %w", err)
+	}
+
+	reports := make([]*dep_manager.CrashReport, len(kvs))
+	for i, kv := range kvs {
+		var report dep_manager.CrashReport
+		if err := kv.Interface(&report); err != nil {
+			return nil, fmt.Errorf("kvs[%d].Interface: %w", i, err)
+		}
+		reports[i] = &report
+	}
+
+	return reports, nil
+}
+
+// replyError returns the error of the failed reply.
+// If the reply has the error code, then it's the dep_manager error that could be checked with errors.Is.
+func replyError(reply message.ReplyInterface) error {
+	errMessage := fmt.Sprintf("reply.Message: %s", reply.ErrorMessage())
+	code := ""
+	if reply.ReplyParameters() != nil {
+		code, _ = reply.ReplyParameters().StringValue(dep_handler.ErrorCode)
+	}
+
+	return dep_manager.FromCode(code, errMessage)
+}
+
+// requestError returns the error of the failed request.
+// If the dep manager didn't reply in time, then it's dep_manager.ErrTimeout.
+func requestError(command string, err error) error {
+	err = fmt.Errorf("socket.Request('%s'): %w", command, err)
+	if dep_manager.SocketTimeout(err) {
+		return &dep_manager.Error{Kind: dep_manager.ErrTimeout, Err: err}
+	}
+
+	return err
+}
+
+// InstallAsync starts the installation in the background.
+// Returns the job id to check with JobStatus, or to subscribe to its progress with NewSubscriber.
+func (c *Client) InstallAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error) {
+	req := message.Request{
+		Command: dep_handler.InstallDep,
+		Parameters: key_value.New().
+			Set("url", url).
+			Set("async", true),
+	}
+	if len(localSrc) > 0 {
+		req.Parameters.Set("local_src", localSrc)
+	}
+	if err := setRef(req.Parameters, optionalRef); err != nil {
+		return "", fmt.Errorf("setRef: %w", err)
+	}
+
+	return c.requestJob(&req)
+}
+
+// UpdateAsync starts the update in the background.
+// Returns the job id to check with JobStatus, or to subscribe to its progress with NewSubscriber.
+func (c *Client) UpdateAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error) {
+	req := message.Request{
+		Command: dep_handler.UpdateDep,
+		Parameters: key_value.New().
+			Set("url", url).
+			Set("async", true),
+	}
+	if len(localSrc) > 0 {
+		req.Parameters.Set("local_src", localSrc)
+	}
+	if err := setRef(req.Parameters, optionalRef); err != nil {
+		return "", fmt.Errorf("setRef: %w", err)
+	}
+
+	return c.requestJob(&req)
+}
+
+// RunAsync starts the dependency in the background.
+// Returns the job id to check with JobStatus.
+func (c *Client) RunAsync(url string, id string, parent *clientConfig.Client, localBin string) (string, error) {
+	req := message.Request{
+		Command: dep_handler.RunDep,
+		Parameters: key_value.New().
+			Set("parent", parent).
+			Set("url", url).
+			Set("id", id).
+			Set("async", true),
+	}
+	if len(localBin) > 0 {
+		req.Parameters.Set("local_bin", localBin)
+	}
+
+	return c.requestJob(&req)
+}
+
+// setRef adds the optional branch, tag and commit to the install parameters
+func setRef(params key_value.KeyValue, optionalRef []*source.Ref) error {
+	if len(optionalRef) > 1 {
+		return fmt.Errorf("too many optional parameters, either no parameter or 1 parameter required")
+	}
+	if len(optionalRef) == 0 || optionalRef[0] == nil {
+		return nil
+	}
+
+	ref := optionalRef[0]
+	if err := ref.IsValid(); err != nil {
+		return fmt.Errorf("ref.IsValid: %w", err)
+	}
+	if len(ref.Branch) > 0 {
+		params.Set("branch", ref.Branch)
+	}
+	if len(ref.Tag) > 0 {
+		params.Set("tag", ref.Tag)
+	}
+	if len(ref.Commit) > 0 {
+		params.Set("commit", ref.Commit)
+	}
+
+	return nil
+}
+
+// requestJob sends the asynchronous request, and returns the job id
+func (c *Client) requestJob(req *message.Request) (string, error) {
+	reply, err := c.socket.Request(req)
+	if err != nil {
+		return "", requestError(req.Command, err)
+	}
+
+	if !reply.IsOK() {
+		return "", replyError(reply)
+	}
+
+	jobId, err := reply.ReplyParameters().StringValue("job_id")
+	if err != nil {
+		return "", fmt.Errorf("reply.Parameters.StringValue('job_id'): %w", err)
+	}
+
+	return jobId, nil
+}
+
+// JobStatus returns the asynchronous install, update or run.
+// The finished jobs are kept by the dep manager for a while only.
+func (c *Client) JobStatus(jobId string) (*dep_handler.Job, error) {
+	req := message.Request{
+		Command:    dep_handler.JobStatus,
+		Parameters: key_value.New().Set("id", jobId),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return nil, requestError(dep_handler.JobStatus, err)
+	}
+
+	if !reply.IsOK() {
+		return nil, replyError(reply)
+	}
+
+	kv, err := reply.ReplyParameters().NestedValue("job")
+	if err != nil {
+		return nil, fmt.Errorf("reply.Parameters.NestedValue('job'): %w", err)
+	}
+
+	var job dep_handler.Job
+	err = kv.Interface(&job)
+	if err != nil {
+		return nil, fmt.Errorf("kv.Interface: %w", err)
+	}
+
+	return &job, nil
+}
+
+// CancelJob cancels the asynchronous install, update or run
+func (c *Client) CancelJob(jobId string) error {
+	req := message.Request{
+		Command:    dep_handler.CancelJob,
+		Parameters: key_value.New().Set("id", jobId),
+	}
+
+	reply, err := c.socket.Request(&req)
+	if err != nil {
+		return requestError(dep_handler.CancelJob, err)
+	}
+
+	if !reply.IsOK() {
+		return replyError(reply)
+	}
+
+	return nil
+}
@@ -0,0 +1,753 @@
+package dep_client
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/dev-lib/dep_handler"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"github.com/ahmetson/dev-lib/source"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The names of the Fake methods that could be scripted to fail with Fake.Fail.
+const (
+	CloseMethod       = "Close"
+	CloseDepMethod    = "CloseDep"
+	UninstallMethod   = "Uninstall"
+	RunMethod         = "Run"
+	RunDetachedMethod = "RunDetached"
+	InstallMethod     = "Install"
+	UpdateMethod      = "Update"
+	RollbackMethod    = "Rollback"
+	RunningMethod     = "Running"
+	ProbeMethod       = "Probe"
+	InstalledMethod   = "Installed"
+
+	SetRestartPolicyMethod = "SetRestartPolicy"
+	SetBuildModeMethod     = "SetBuildMode"
+	RestartStatusMethod    = "RestartStatus"
+	SetGroupMethod         = "SetGroup"
+	RemoveGroupMethod      = "RemoveGroup"
+	SetGraphMethod         = "SetGraph"
+	GraphMethod            = "Graph"
+	RunGraphMethod         = "RunGraph"
+	StopGraphMethod        = "StopGraph"
+	InstallAsyncMethod     = "InstallAsync"
+	UpdateAsyncMethod      = "UpdateAsync"
+	RunAsyncMethod         = "RunAsync"
+	JobStatusMethod        = "JobStatus"
+	CancelJobMethod        = "CancelJob"
+	StopMethod             = "Stop"
+	ListMethod             = "List"
+	InfoMethod             = "Info"
+	LogsMethod             = "Logs"
+	LogsFromMethod         = "LogsFrom"
+	CrashReportsMethod     = "CrashReports"
+	WaitReadyMethod        = "WaitReady"
+	HealthStatusMethod     = "HealthStatus"
+	UpdateLockMethod       = "UpdateLock"
+)
+
+// A Call is the recorded invocation of the Fake client
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fake is the in-process dep client that keeps the dependencies in the memory.
+// It doesn't build or spawn anything.
+// The calls are recorded, and any method could be scripted to fail.
+//
+// Intended to be used by the test context.
+type Fake struct {
+	mu        sync.Mutex
+	calls     []*Call
+	failures  map[string]error
+	installed map[string]bool // url => installed
+	running   map[string]bool // dependency id => running
+	policies  map[string]*dep_manager.RestartPolicy
+	groups    map[string]*dep_manager.Group
+	graph     *dep_manager.Graph
+	jobs      map[string]*dep_handler.Job
+	logs      map[string][]string // dependency id => output lines
+	timeout   time.Duration
+	attempt   uint8
+	closed    bool
+}
+
+// NewFake returns a dep client with nothing installed and nothing running
+func NewFake() *Fake {
+	return &Fake{
+		calls:     make([]*Call, 0),
+		failures:  make(map[string]error),
+		installed: make(map[string]bool),
+		running:   make(map[string]bool),
+		policies:  make(map[string]*dep_manager.RestartPolicy),
+		groups:    make(map[string]*dep_manager.Group),
+		jobs:      make(map[string]*dep_handler.Job),
+		logs:      make(map[string][]string),
+	}
+}
+
+// Fail makes the method to return the given error.
+// Pass nil error to make the method succeed again.
+func (f *Fake) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// SetInstalled marks the dependency by its url as installed or not
+func (f *Fake) SetInstalled(url string, installed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.installed[url] = installed
+}
+
+// SetRunning marks the dependency by its id as running or not
+func (f *Fake) SetRunning(id string, running bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.running[id] = running
+}
+
+// WriteLogs adds the output lines of the dependency by its id
+func (f *Fake) WriteLogs(id string, lines ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.logs[id] = append(f.logs[id], lines...)
+}
+
+// Calls returns the recorded calls.
+// If the methods are given, then returns the calls of these methods only.
+func (f *Fake) Calls(methods ...string) []*Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]*Call, 0, len(f.calls))
+	for _, call := range f.calls {
+		if len(methods) == 0 {
+			calls = append(calls, call)
+			continue
+		}
+		for _, method := range methods {
+			if call.Method == method {
+				calls = append(calls, call)
+				break
+			}
+		}
+	}
+
+	return calls
+}
+
+// IsClosed returns true if the Fake.Close was called.
+func (f *Fake) IsClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.closed
+}
+
+// record the call and return the scripted error.
+// The caller must lock the mutex.
+func (f *Fake) record(method string, args ...interface{}) error {
+	f.calls = append(f.calls, &Call{Method: method, Args: args})
+	return f.failures[method]
+}
+
+// Timeout is stored, but not used
+func (f *Fake) Timeout(duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.timeout = duration
+}
+
+// Attempt is stored, but not used
+func (f *Fake) Attempt(attempt uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.attempt = attempt
+}
+
+func (f *Fake) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(CloseMethod); err != nil {
+		return err
+	}
+	f.closed = true
+
+	return nil
+}
+
+// CloseDep marks the dependency by the client id as not running
+func (f *Fake) CloseDep(depClient *clientConfig.Client) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(CloseDepMethod, depClient); err != nil {
+		return err
+	}
+	if depClient == nil {
+		return fmt.Errorf("nil dep client")
+	}
+	f.running[depClient.Id] = false
+
+	return nil
+}
+
+// Uninstall marks the dependency as not installed
+func (f *Fake) Uninstall(url string, localSrc string, localBin string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(UninstallMethod, url, localSrc, localBin); err != nil {
+		return err
+	}
+	f.installed[url] = false
+
+	return nil
+}
+
+// Run marks the dependency by id as running
+func (f *Fake) Run(url string, id string, parent *clientConfig.Client, localBin string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RunMethod, url, id, parent, localBin); err != nil {
+		return err
+	}
+	if f.running[id] {
+		return fmt.Errorf("the '%s' %w", id, dep_manager.ErrAlreadyRunning)
+	}
+	f.running[id] = true
+
+	return nil
+}
+
+// RunDetached marks the dependency by id as running
+func (f *Fake) RunDetached(url string, id string, parent *clientConfig.Client, depClient *clientConfig.Client, localBin string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RunDetachedMethod, url, id, parent, depClient, localBin); err != nil {
+		return err
+	}
+	if f.running[id] {
+		return fmt.Errorf("the '%s' %w", id, dep_manager.ErrAlreadyRunning)
+	}
+	f.running[id] = true
+
+	return nil
+}
+
+// Install marks the dependency as installed
+func (f *Fake) Install(url string, localSrc string, optionalRef ...*source.Ref) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(InstallMethod, url, localSrc, optionalRef); err != nil {
+		return err
+	}
+	f.installed[url] = true
+
+	return nil
+}
+
+// Update succeeds if the dependency was installed, as the fake dependencies have no remote repository
+func (f *Fake) Update(url string, localSrc string, optionalRef ...*source.Ref) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(UpdateMethod, url, localSrc, optionalRef); err != nil {
+		return err
+	}
+	if !f.installed[url] {
+		return fmt.Errorf("the '%s' %w", url, dep_manager.ErrNotInstalled)
+	}
+
+	return nil
+}
+
+// Rollback always fails with dep_manager.ErrNoPrevious, as the fake dependencies are never built
+func (f *Fake) Rollback(url string) (*dep_manager.BinVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RollbackMethod, url); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("the '%s' %w", url, dep_manager.ErrNoPrevious)
+}
+
+// Running returns true if the dependency by the client id was run
+func (f *Fake) Running(depClient *clientConfig.Client) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RunningMethod, depClient); err != nil {
+		return false, err
+	}
+	if depClient == nil {
+		return false, fmt.Errorf("nil dep client")
+	}
+
+	return f.running[depClient.Id], nil
+}
+
+// Probe returns the successful result if the dependency by the client id was run, otherwise it's refused
+func (f *Fake) Probe(depClient *clientConfig.Client) (*dep_manager.ProbeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(ProbeMethod, depClient); err != nil {
+		return nil, err
+	}
+	if depClient == nil {
+		return nil, fmt.Errorf("nil dep client")
+	}
+
+	result := &dep_manager.ProbeResult{Id: depClient.Id, Reachable: f.running[depClient.Id]}
+	if !result.Reachable {
+		result.Failure = dep_manager.ProbeRefused
+	}
+
+	return result, nil
+}
+
+// Installed returns true if the dependency was installed
+func (f *Fake) Installed(url string, localBin string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(InstalledMethod, url, localBin); err != nil {
+		return false, err
+	}
+
+	return f.installed[url], nil
+}
+
+// SetBuildMode validates the mode only, as the fake dependencies are never built
+func (f *Fake) SetBuildMode(url string, mode dep_manager.BuildMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetBuildModeMethod, url, mode); err != nil {
+		return err
+	}
+
+	return dep_manager.IsValidBuildMode(mode)
+}
+
+// SetRestartPolicy stores the policy. The fake dependencies are never restarted.
+func (f *Fake) SetRestartPolicy(id string, policy *dep_manager.RestartPolicy) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetRestartPolicyMethod, id, policy); err != nil {
+		return err
+	}
+	if policy == nil {
+		delete(f.policies, id)
+		return nil
+	}
+	if err := policy.IsValid(); err != nil {
+		return fmt.Errorf("policy.IsValid: %w", err)
+	}
+	f.policies[id] = policy
+
+	return nil
+}
+
+// RestartStatus returns the status without any restarts
+func (f *Fake) RestartStatus(id string) (*dep_manager.RestartStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RestartStatusMethod, id); err != nil {
+		return nil, err
+	}
+
+	return &dep_manager.RestartStatus{Id: id}, nil
+}
+
+// SetGroup stores the group. The fake dependencies are never restarted.
+func (f *Fake) SetGroup(group *dep_manager.Group) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetGroupMethod, group); err != nil {
+		return err
+	}
+	if err := group.IsValid(); err != nil {
+		return fmt.Errorf("group.IsValid: %w", err)
+	}
+	f.groups[group.Id] = group
+
+	return nil
+}
+
+// RemoveGroup deletes the stored group
+func (f *Fake) RemoveGroup(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RemoveGroupMethod, id); err != nil {
+		return err
+	}
+	delete(f.groups, id)
+
+	return nil
+}
+
+// SetGraph stores the graph
+func (f *Fake) SetGraph(graph *dep_manager.Graph) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetGraphMethod, graph); err != nil {
+		return err
+	}
+	if err := graph.IsValid(); err != nil {
+		return fmt.Errorf("graph.IsValid: %w", err)
+	}
+	f.graph = graph
+
+	return nil
+}
+
+// Graph returns the start order of the stored graph, or nil if there is no graph
+func (f *Fake) Graph() (*dep_manager.ResolvedGraph, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(GraphMethod); err != nil {
+		return nil, err
+	}
+	if f.graph == nil {
+		return nil, nil
+	}
+
+	return f.graph.Resolve()
+}
+
+// RunGraph marks the dependencies as running.
+// The required dependency must be in the deps or be running already.
+func (f *Fake) RunGraph(deps []*dep_handler.GraphDep, parent *clientConfig.Client) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RunGraphMethod, deps, parent); err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		ids[dep.Id] = true
+	}
+	for _, dep := range deps {
+		if f.running[dep.Id] {
+			return fmt.Errorf("the '%s' %w", dep.Id, dep_manager.ErrAlreadyRunning)
+		}
+		if f.graph == nil {
+			continue
+		}
+		for _, requiredId := range f.graph.Requires[dep.Id] {
+			if !ids[requiredId] && !f.running[requiredId] {
+				return fmt.Errorf("the '%s' dependency requires '%s' that is not running", dep.Id, requiredId)
+			}
+		}
+	}
+	for id := range ids {
+		f.running[id] = true
+	}
+
+	return nil
+}
+
+// StopGraph marks the dependencies of the stored graph as not running
+func (f *Fake) StopGraph() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(StopGraphMethod); err != nil {
+		return err
+	}
+	if f.graph == nil {
+		return fmt.Errorf("no graph. Call Fake.SetGraph first")
+	}
+	for id := range f.graph.Requires {
+		f.running[id] = false
+	}
+
+	return nil
+}
+
+// InstallAsync marks the dependency as installed.
+// The job is finished at once.
+func (f *Fake) InstallAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(InstallAsyncMethod, url, localSrc, optionalRef); err != nil {
+		return "", err
+	}
+	f.installed[url] = true
+
+	return f.addJob(dep_handler.InstallDep, url, nil), nil
+}
+
+// UpdateAsync finishes the job at once.
+// If the dependency was not installed, then the job fails.
+func (f *Fake) UpdateAsync(url, localSrc string, optionalRef ...*source.Ref) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(UpdateAsyncMethod, url, localSrc, optionalRef); err != nil {
+		return "", err
+	}
+	var err error
+	if !f.installed[url] {
+		err = fmt.Errorf("the '%s' %w", url, dep_manager.ErrNotInstalled)
+	}
+
+	return f.addJob(dep_handler.UpdateDep, url, err), nil
+}
+
+// RunAsync marks the dependency by id as running.
+// The job is finished at once. If the dependency is running, then the job fails.
+func (f *Fake) RunAsync(url string, id string, parent *clientConfig.Client, localBin string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(RunAsyncMethod, url, id, parent, localBin); err != nil {
+		return "", err
+	}
+	var err error
+	if f.running[id] {
+		err = fmt.Errorf("the '%s' %w", id, dep_manager.ErrAlreadyRunning)
+	}
+	f.running[id] = true
+
+	return f.addJob(dep_handler.RunDep, url, err), nil
+}
+
+// addJob adds the finished job.
+// The caller must lock the mutex.
+func (f *Fake) addJob(command string, url string, err error) string {
+	now := time.Now()
+	job := &dep_handler.Job{
+		Id:      fmt.Sprintf("job-%d", len(f.jobs)+1),
+		Command: command,
+		Url:     url,
+		State:   dep_handler.JobDone,
+		Created: now,
+		Updated: now,
+	}
+	if err != nil {
+		job.State = dep_handler.JobFailed
+		job.Error = err.Error()
+		job.ErrorCode = dep_manager.ErrorCode(err)
+	}
+	f.jobs[job.Id] = job
+
+	return job.Id
+}
+
+// JobStatus returns the copy of the job
+func (f *Fake) JobStatus(jobId string) (*dep_handler.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(JobStatusMethod, jobId); err != nil {
+		return nil, err
+	}
+	job, ok := f.jobs[jobId]
+	if !ok {
+		return nil, &dep_manager.Error{Kind: dep_manager.ErrNotFound, Err: fmt.Errorf("no '%s' job", jobId)}
+	}
+	jobCopy := *job
+
+	return &jobCopy, nil
+}
+
+// CancelJob always fails, as the fake jobs are finished at once
+func (f *Fake) CancelJob(jobId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(CancelJobMethod, jobId); err != nil {
+		return err
+	}
+	job, ok := f.jobs[jobId]
+	if !ok {
+		return &dep_manager.Error{Kind: dep_manager.ErrNotFound, Err: fmt.Errorf("no '%s' job", jobId)}
+	}
+
+	return fmt.Errorf("the '%s' job is %s already", jobId, job.State)
+}
+
+// Stop marks the dependency by id as not running.
+// The fake dependency always exits with 0 code after the close command.
+func (f *Fake) Stop(id string) (*dep_manager.ExitStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(StopMethod, id); err != nil {
+		return nil, err
+	}
+	if !f.running[id] {
+		return nil, fmt.Errorf("the '%s' dep is not running", id)
+	}
+	f.running[id] = false
+
+	return &dep_manager.ExitStatus{Code: 0, Stage: dep_manager.StopClosed}, nil
+}
+
+// List returns the running dependencies sorted by id.
+// The fake dependencies have only the id.
+func (f *Fake) List() ([]*dep_manager.DepInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(ListMethod); err != nil {
+		return nil, err
+	}
+	infos := make([]*dep_manager.DepInfo, 0, len(f.running))
+	for id, running := range f.running {
+		if running {
+			infos = append(infos, &dep_manager.DepInfo{Id: id})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Id < infos[j].Id
+	})
+
+	return infos, nil
+}
+
+// Info returns the running dependency with the id only
+func (f *Fake) Info(id string) (*dep_manager.DepInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(InfoMethod, id); err != nil {
+		return nil, err
+	}
+	if !f.running[id] {
+		return nil, fmt.Errorf("the '%s' dep is not running", id)
+	}
+
+	return &dep_manager.DepInfo{Id: id}, nil
+}
+
+// Logs returns the last lines written by Fake.WriteLogs
+func (f *Fake) Logs(id string, lines int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(LogsMethod, id, lines); err != nil {
+		return nil, err
+	}
+	output, ok := f.logs[id]
+	if !ok {
+		return nil, &dep_manager.Error{Kind: dep_manager.ErrNotFound, Err: fmt.Errorf("no '%s' logs", id)}
+	}
+	if lines < 0 {
+		lines = 0
+	}
+	if len(output) > lines {
+		output = output[len(output)-lines:]
+	}
+
+	return append([]string{}, output...), nil
+}
+
+// LogsFrom returns the lines written by Fake.WriteLogs after the offset.
+// The offset of the fake logs is the amount of the lines.
+func (f *Fake) LogsFrom(id string, offset int64) ([]string, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(LogsFromMethod, id, offset); err != nil {
+		return nil, 0, err
+	}
+	output, ok := f.logs[id]
+	if !ok {
+		return nil, 0, &dep_manager.Error{Kind: dep_manager.ErrNotFound, Err: fmt.Errorf("no '%s' logs", id)}
+	}
+	if offset < 0 || offset > int64(len(output)) {
+		offset = 0
+	}
+
+	return append([]string{}, output[offset:]...), int64(len(output)), nil
+}
+
+// UpdateLock returns no updated entries, as the fake dependencies have no remote repository
+func (f *Fake) UpdateLock(url string) ([]*dep_manager.LockEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(UpdateLockMethod, url); err != nil {
+		return nil, err
+	}
+
+	return []*dep_manager.LockEntry{}, nil
+}
+
+// CrashReports returns no reports, as the fake dependencies never exit
+func (f *Fake) CrashReports(id string) ([]*dep_manager.CrashReport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(CrashReportsMethod, id); err != nil {
+		return nil, err
+	}
+
+	return []*dep_manager.CrashReport{}, nil
+}
+
+// WaitReady returns at once. If the dependency is not running, then it's dep_manager.ErrExited.
+func (f *Fake) WaitReady(id string, depClient *clientConfig.Client, timeout time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(WaitReadyMethod, id, depClient, timeout); err != nil {
+		return err
+	}
+	if !f.running[id] {
+		return fmt.Errorf("the '%s' dep: %w", id, dep_manager.ErrExited)
+	}
+
+	return nil
+}
+
+// HealthStatus returns the healthy status for the running dependency
+func (f *Fake) HealthStatus(id string) (*dep_manager.HealthStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(HealthStatusMethod, id); err != nil {
+		return nil, err
+	}
+	if !f.running[id] {
+		return &dep_manager.HealthStatus{Id: id, Health: dep_manager.HealthUnknown}, nil
+	}
+
+	return &dep_manager.HealthStatus{Id: id, Health: dep_manager.HealthHealthy}, nil
+}
@@ -0,0 +1,56 @@
+package dep_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultFollowInterval is the time between the requests of the new dependency output
+const DefaultFollowInterval = time.Millisecond * 500
+
+// FollowLogs passes the dependency output to the fn line by line, until the context is done.
+// It starts with the last lines of the current log file, then polls the new lines every interval.
+//
+// The lines must not be negative. Pass 0 interval to use DefaultFollowInterval.
+// The client socket is not thread-safe, so don't use the client until FollowLogs returns.
+func FollowLogs(ctx context.Context, c Interface, id string, lines int, interval time.Duration, fn func(line string)) error {
+	if lines < 0 {
+		return fmt.Errorf("the lines %d is negative", lines)
+	}
+	if interval <= 0 {
+		interval = DefaultFollowInterval
+	}
+
+	// the current log file, the rotated files are not followed
+	last, offset, err := c.LogsFrom(id, 0)
+	if err != nil {
+		return fmt.Errorf("c.LogsFrom('%s', 0): %w", id, err)
+	}
+	if len(last) > lines {
+		last = last[len(last)-lines:]
+	}
+	for _, line := range last {
+		fn(line)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var newLines []string
+		newLines, offset, err = c.LogsFrom(id, offset)
+		if err != nil {
+			return fmt.Errorf("c.LogsFrom('%s', %d): %w", id, offset, err)
+		}
+		for _, line := range newLines {
+			fn(line)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package dep_client
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestLogsSuite struct {
+	suite.Suite
+
+	client *Fake
+}
+
+func (test *TestLogsSuite) SetupTest() {
+	test.client = NewFake()
+	test.client.WriteLogs("dep", "first", "second", "third")
+}
+
+// Test_10_FollowLogs tests that FollowLogs starts with the last lines
+func (test *TestLogsSuite) Test_10_FollowLogs() {
+	s := test.Require
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	lines := make([]string, 0, 2)
+	err := FollowLogs(ctx, test.client, "dep", 2, time.Millisecond*10, func(line string) {
+		lines = append(lines, line)
+	})
+	s().NoError(err)
+	s().Equal([]string{"second", "third"}, lines)
+}
+
+// Test_11_FollowLogsNegative tests that FollowLogs rejects the negative lines
+func (test *TestLogsSuite) Test_11_FollowLogsNegative() {
+	s := test.Require
+
+	err := FollowLogs(context.Background(), test.client, "dep", -1, 0, func(string) {
+		s().Fail("no line is expected")
+	})
+	s().Error(err)
+	s().Empty(test.client.Calls(LogsFromMethod))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestLogs(t *testing.T) {
+	suite.Run(t, new(TestLogsSuite))
+}
@@ -0,0 +1,73 @@
+package dep_client
+
+import (
+	"fmt"
+	"github.com/ahmetson/dev-lib/dep_handler"
+	zmq "github.com/pebbe/zmq4"
+	"time"
+)
+
+// Subscriber receives the progress of the asynchronous jobs.
+// The subscriber must be used by a single goroutine.
+type Subscriber struct {
+	socket *zmq.Socket
+}
+
+// NewSubscriber connects to the progress of the jobs.
+// If no job ids are given, then the progress of all jobs is received.
+//
+// Subscribe before starting the job to not miss the messages,
+// or check the job status after subscribing.
+func NewSubscriber(jobIds ...string) (*Subscriber, error) {
+	socket, err := zmq.NewSocket(zmq.SUB)
+	if err != nil {
+		return nil, fmt.Errorf("zmq.NewSocket: %w", err)
+	}
+
+	if err := socket.Connect(dep_handler.ProgressUrl()); err != nil {
+		_ = socket.Close()
+		return nil, fmt.Errorf("socket.Connect('%s'): %w", dep_handler.ProgressUrl(), err)
+	}
+
+	if len(jobIds) == 0 {
+		jobIds = []string{""}
+	}
+	for _, jobId := range jobIds {
+		if err := socket.SetSubscribe(jobId); err != nil {
+			_ = socket.Close()
+			return nil, fmt.Errorf("socket.SetSubscribe('%s'): %w", jobId, err)
+		}
+	}
+
+	return &Subscriber{socket: socket}, nil
+}
+
+// Timeout sets the time to wait for the progress in Recv.
+// By default, Recv waits forever.
+func (s *Subscriber) Timeout(timeout time.Duration) error {
+	if err := s.socket.SetRcvtimeo(timeout); err != nil {
+		return fmt.Errorf("socket.SetRcvtimeo: %w", err)
+	}
+	return nil
+}
+
+// Recv returns the next progress.
+// The progress with the JobDone or JobFailed state is the last progress of the job.
+func (s *Subscriber) Recv() (*dep_handler.Progress, error) {
+	messages, err := s.socket.RecvMessage(0)
+	if err != nil {
+		return nil, fmt.Errorf("socket.RecvMessage: %w", err)
+	}
+
+	progress, err := dep_handler.ParseProgress(messages)
+	if err != nil {
+		return nil, fmt.Errorf("dep_handler.ParseProgress: %w", err)
+	}
+
+	return progress, nil
+}
+
+// Close the subscriber
+func (s *Subscriber) Close() error {
+	return s.socket.Close()
+}
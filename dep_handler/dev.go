@@ -2,31 +2,66 @@
 package dep_handler
 
 import (
+	"context"
 	"fmt"
 	clientConfig "github.com/ahmetson/client-lib/config"
 	"github.com/ahmetson/datatype-lib/data_type/key_value"
 	"github.com/ahmetson/datatype-lib/message"
 	"github.com/ahmetson/dev-lib/dep_manager"
+	"github.com/ahmetson/dev-lib/source"
 	"github.com/ahmetson/handler-lib/base"
 	handlerConfig "github.com/ahmetson/handler-lib/config"
 	"github.com/ahmetson/handler-lib/replier"
 	"github.com/ahmetson/log-lib"
+	"time"
 )
 
 const (
 	Category     = "dep_handler"   // handler category
 	DepInstalled = "dep-installed" // the command to check is dependency installed
 	DepRunning   = "dep-running"   // the command to check is dependency running
+	ProbeDep     = "probe-dep"     // the command to send the heartbeat and get the details of the dependency
 	InstallDep   = "install-dep"   // the command to install the dependency
+	UpdateDep    = "update-dep"    // the command to fetch the latest source code and rebuild the dependency
+	RollbackDep  = "rollback-dep"  // the command to install the binary built before the current one
 	RunDep       = "run-dep"       // the command to run the dependency
 	UninstallDep = "uninstall-dep" // the command to remove the dependency binary. if possible, then remove the source code as well.
 	CloseDep     = "close-dep"     // the command to stop the running dependency
+	StopDep      = "stop-dep"      // the command to stop the spawned dependency by its id
+	ListDeps     = "list-deps"     // the command to get the running dependencies
+	DepInfo      = "dep-info"      // the command to get the running dependency by its id
+	DepLogs      = "dep-logs"      // the command to get the output of the dependency
+	CrashReports = "crash-reports" // the command to get the last exits of the dependency
+	WaitReady    = "wait-ready"    // the command to wait until the dependency replies to the heartbeat
+	HealthStatus = "health-status" // the command to get the health of the running dependency
+	UpdateLock   = "update-lock"   // the command to update the locked commits of the dependencies
+
+	SetRestartPolicy = "set-restart-policy" // the command to set the restart policy of the dependency
+	SetBuildMode     = "set-build-mode"     // the command to set how the dependency modules are treated during the build
+	RestartStatus    = "restart-status"     // the command to get the restarts of the dependency
+	SetDepGroup      = "set-dep-group"      // the command to add the group of the dependencies
+	RemoveDepGroup   = "remove-dep-group"   // the command to delete the group of the dependencies
+	SetDepGraph      = "set-dep-graph"      // the command to set the dependencies between the dependencies
+	DepGraph         = "dep-graph"          // the command to get the start order of the dependencies
+	RunDepGraph      = "run-dep-graph"      // the command to run the dependencies in the order of the graph
+	StopDepGraph     = "stop-dep-graph"     // the command to stop the dependencies of the graph in the reverse order
+
+	JobStatus = "job-status" // the command to get the asynchronous install, update or run
+	CancelJob = "cancel-job" // the command to cancel the asynchronous install, update or run
 )
 
+// UpdateLockTimeout is the limit of the remote repository lookups by the UpdateLock command
+const UpdateLockTimeout = time.Second * 30
+
+// ErrorCode is the reply parameter with the code of the dep_manager error.
+// The dep_client converts it back to the dep_manager error.
+const ErrorCode = "error_code"
+
 type DepHandler struct {
 	handler base.Interface
 	manager dep_manager.Interface
 	logger  *log.Logger
+	jobs    *jobTable
 }
 
 // ServiceConfig returns the socket configuration of the handler
@@ -53,9 +88,21 @@ func New(manager dep_manager.Interface) (*DepHandler, error) {
 		manager: manager,
 		handler: handler,
 		logger:  logger,
+		jobs:    newJobTable(),
 	}, nil
 }
 
+// fail returns the failed reply.
+// If the error is the dep_manager error, then its code is passed as the ErrorCode parameter.
+func fail(req message.RequestInterface, message string, err error) message.ReplyInterface {
+	reply := req.Fail(message)
+	if code := dep_manager.ErrorCode(err); len(code) > 0 {
+		reply.ReplyParameters().Set(ErrorCode, code)
+	}
+
+	return reply
+}
+
 // onDepInstalled checks whether the dependency installed or not.
 // Requires:
 //   - 'url' string
@@ -102,26 +149,61 @@ func (h *DepHandler) onDepRunning(req message.RequestInterface) message.ReplyInt
 
 	running, err := h.manager.Running(&c)
 	if err != nil {
-		return req.Fail(fmt.Sprintf("h.manager.Running: %v", err))
+		return fail(req, fmt.Sprintf("h.manager.Running: %v", err), err)
 	}
 
 	params := key_value.New().Set("running", running)
 	return req.Ok(params)
 }
 
+// onProbeDep sends the heartbeat to the dependency.
+// Requires:
+//   - 'dep' of the clientConfig.Client.
+//
+// Returns 'probe' of the dep_manager.ProbeResult type.
+func (h *DepHandler) onProbeDep(req message.RequestInterface) message.ReplyInterface {
+	kv, err := req.RouteParameters().NestedValue("dep")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('dep'): %v", err))
+	}
+
+	var c clientConfig.Client
+	err = kv.Interface(&c)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+	}
+
+	result, err := h.manager.Probe(&c)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Probe: %v", err), err)
+	}
+
+	probe, err := key_value.NewFromInterface(result)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(result): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("probe", probe))
+}
+
 // onInstallDep installs the dependency. if it comes with the source code, then build that as well.
 //
 // Requires:
 //
-//   - 'url' string type
+//   - 'url' string type. It may have the version, see source.New
 //
 //   - 'branch' string type, optionally
 //
+//   - 'tag' string type, optionally. It can't be set along with the 'branch'.
+//
+//   - 'commit' string type, optionally. The hash or its prefix to check out after the clone.
+//
 //   - 'local_src' string type, optionally
 //
-//     returns nothing.
+//   - 'async' boolean, optionally. If it's true, then the installation runs in the background.
 //
-// todo create a publisher that publishes the result of the installation, so user won't wait until installation.
+//     returns 'job_id' string if it's async. Otherwise, returns nothing.
+//     The progress of the job is published to the ProgressUrl.
 func (h *DepHandler) onInstallDep(req message.RequestInterface) message.ReplyInterface {
 	url, err := req.RouteParameters().StringValue("url")
 	if err != nil {
@@ -129,6 +211,8 @@ func (h *DepHandler) onInstallDep(req message.RequestInterface) message.ReplyInt
 	}
 
 	optionalBranch, _ := req.RouteParameters().StringValue("branch")
+	optionalTag, _ := req.RouteParameters().StringValue("tag")
+	optionalCommit, _ := req.RouteParameters().StringValue("commit")
 	optionalLocalSrc, _ := req.RouteParameters().StringValue("local_src")
 
 	dep, err := dep_manager.NewDep(url, optionalLocalSrc, "")
@@ -136,27 +220,140 @@ func (h *DepHandler) onInstallDep(req message.RequestInterface) message.ReplyInt
 		return req.Fail(fmt.Sprintf("dep_manager.NewDep('%s', '%s', ''): %v", url, optionalLocalSrc, err))
 	}
 	h.manager.Lint(dep)
-	if len(optionalBranch) > 0 {
-		dep.SetBranch(optionalBranch)
+	// the parameters override the version in the url
+	ref := &source.Ref{Branch: optionalBranch, Tag: optionalTag, Commit: optionalCommit}
+	if len(ref.String()) > 0 {
+		if err := dep.SetRef(ref); err != nil {
+			return req.Fail(fmt.Sprintf("dep.SetRef: %v", err))
+		}
+	}
+
+	async, _ := req.RouteParameters().BoolValue("async")
+	if async {
+		job := h.jobs.add(InstallDep, url)
+		h.jobs.run(job, func(ctx context.Context, progress *jobProgress) error {
+			dep.SetProgress(progress)
+			return h.manager.InstallContext(ctx, dep, h.logger)
+		})
+
+		return req.Ok(key_value.New().Set("job_id", job.Id))
 	}
 
 	err = h.manager.Install(dep, h.logger)
 	if err != nil {
-		return req.Fail(fmt.Sprintf("h.manager.Install: %v", err))
+		return fail(req, fmt.Sprintf("h.manager.Install: %v", err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onUpdateDep fetches the latest commit of the dependency, and rebuilds it.
+// If the build fails, then the previous binary is restored.
+//
+// Requires:
+//
+//   - 'url' string type. It may have the version, see source.New
+//
+//   - 'branch' string type, optionally
+//
+//   - 'tag' string type, optionally. It can't be set along with the 'branch'.
+//
+//   - 'commit' string type, optionally
+//
+//   - 'local_src' string type, optionally. The local source code is rebuilt without fetching.
+//
+//   - 'async' boolean, optionally. If it's true, then the update runs in the background.
+//
+//     returns 'job_id' string if it's async. Otherwise, returns nothing.
+//     The progress of the job is published to the ProgressUrl.
+func (h *DepHandler) onUpdateDep(req message.RequestInterface) message.ReplyInterface {
+	url, err := req.RouteParameters().StringValue("url")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.StringValue('url'): %v", err))
+	}
+
+	optionalBranch, _ := req.RouteParameters().StringValue("branch")
+	optionalTag, _ := req.RouteParameters().StringValue("tag")
+	optionalCommit, _ := req.RouteParameters().StringValue("commit")
+	optionalLocalSrc, _ := req.RouteParameters().StringValue("local_src")
+
+	dep, err := dep_manager.NewDep(url, optionalLocalSrc, "")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("dep_manager.NewDep('%s', '%s', ''): %v", url, optionalLocalSrc, err))
+	}
+	h.manager.Lint(dep)
+	// the parameters override the version in the url
+	ref := &source.Ref{Branch: optionalBranch, Tag: optionalTag, Commit: optionalCommit}
+	if len(ref.String()) > 0 {
+		if err := dep.SetRef(ref); err != nil {
+			return req.Fail(fmt.Sprintf("dep.SetRef: %v", err))
+		}
+	}
+
+	async, _ := req.RouteParameters().BoolValue("async")
+	if async {
+		job := h.jobs.add(UpdateDep, url)
+		h.jobs.run(job, func(ctx context.Context, progress *jobProgress) error {
+			dep.SetProgress(progress)
+			return h.manager.UpdateContext(ctx, dep, h.logger)
+		})
+
+		return req.Ok(key_value.New().Set("job_id", job.Id))
+	}
+
+	err = h.manager.Update(dep, h.logger)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Update: %v", err), err)
 	}
 
 	return req.Ok(key_value.New())
 }
 
+// onRollbackDep installs the binary of the dependency built before the installed one.
+//
+// The binary set by the user has no kept versions, so it can't be rolled back.
+//
+// Requires:
+//
+//   - 'url' string type.
+//
+// Returns 'version' of the dep_manager.BinVersion type.
+func (h *DepHandler) onRollbackDep(req message.RequestInterface) message.ReplyInterface {
+	url, err := req.RouteParameters().StringValue("url")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.StringValue('url'): %v", err))
+	}
+
+	dep, err := dep_manager.NewDep(url, "", "")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("dep_manager.NewDep('%s', '', ''): %v", url, err))
+	}
+	h.manager.Lint(dep)
+
+	version, err := h.manager.Rollback(dep)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Rollback: %v", err), err)
+	}
+
+	kv, err := key_value.NewFromInterface(version)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(version): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("version", kv))
+}
+
 // onRunDep runs the dependency.
 // Requires:
 //   - 'url' string parameter,
 //   - 'id' string parameter,
 //   - 'parent' of the clientConfig.Client type.
 //   - 'local_bin' string, optionally
+//   - 'detach' boolean, optionally. If it's true, then dependency keeps running after this service exits.
+//   - 'manager' of the clientConfig.Client type, optionally. The socket of the dependency itself.
+//   - 'async' boolean, optionally. If it's true, then the dependency is started in the background.
 //
-// Returns nothing.
-// todo make it publish the result through publisher, so user won't wait for the result.
+// Returns 'job_id' string if it's async. Otherwise, returns nothing.
 func (h *DepHandler) onRunDep(req message.RequestInterface) message.ReplyInterface {
 	kv, err := req.RouteParameters().NestedValue("parent")
 	if err != nil {
@@ -189,9 +386,45 @@ func (h *DepHandler) onRunDep(req message.RequestInterface) message.ReplyInterfa
 	}
 	h.manager.Lint(dep)
 
+	detach, _ := req.RouteParameters().BoolValue("detach")
+	dep.SetDetached(detach)
+
+	if req.RouteParameters().Exist("manager") {
+		kv, err := req.RouteParameters().NestedValue("manager")
+		if err != nil {
+			return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('manager'): %v", err))
+		}
+
+		var depManager clientConfig.Client
+		err = kv.Interface(&depManager)
+		if err != nil {
+			return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+		}
+		depManager.UrlFunc(clientConfig.Url)
+		dep.SetManager(&depManager)
+	}
+
+	if req.RouteParameters().Exist("ready_timeout") {
+		readyTimeout, err := req.RouteParameters().Uint64Value("ready_timeout")
+		if err != nil {
+			return req.Fail(fmt.Sprintf("req.Parameters.GetUint64('ready_timeout'): %v", err))
+		}
+		dep.SetReadyTimeout(time.Duration(readyTimeout) * time.Millisecond)
+	}
+
+	async, _ := req.RouteParameters().BoolValue("async")
+	if async {
+		job := h.jobs.add(RunDep, url)
+		h.jobs.run(job, func(context.Context, *jobProgress) error {
+			return h.manager.Run(dep, id, &parent)
+		})
+
+		return req.Ok(key_value.New().Set("job_id", job.Id))
+	}
+
 	err = h.manager.Run(dep, id, &parent)
 	if err != nil {
-		return req.Fail(fmt.Sprintf("h.manager.Start(url: '%s', id: '%s'): %v", url, id, err))
+		return fail(req, fmt.Sprintf("h.manager.Start(url: '%s', id: '%s'): %v", url, id, err), err)
 	}
 
 	return req.Ok(key_value.New())
@@ -225,7 +458,7 @@ func (h *DepHandler) onUninstallDep(req message.RequestInterface) message.ReplyI
 
 	err = h.manager.Uninstall(dep)
 	if err != nil {
-		return req.Fail(fmt.Sprintf("h.manager.Uninstall: %v", err))
+		return fail(req, fmt.Sprintf("h.manager.Uninstall: %v", err), err)
 	}
 
 	return req.Ok(key_value.New())
@@ -252,20 +485,503 @@ func (h *DepHandler) onCloseDep(req message.RequestInterface) message.ReplyInter
 
 	err = h.manager.Close(&c)
 	if err != nil {
-		return req.Fail(fmt.Sprintf("h.manager.Close: %v", err))
+		return fail(req, fmt.Sprintf("h.manager.Close: %v", err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onWaitReady waits until the running dependency replies to the heartbeat.
+// Requires:
+//   - 'id' string parameter.
+//   - 'timeout' number parameter in milliseconds.
+//   - 'dep' of the clientConfig.Client type, optionally. If it's not given, then the socket the dependency was run with is used.
+//
+// Returns nothing.
+func (h *DepHandler) onWaitReady(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	timeout, err := req.RouteParameters().Uint64Value("timeout")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetUint64('timeout'): %v", err))
+	}
+
+	var c *clientConfig.Client
+	if req.RouteParameters().Exist("dep") {
+		kv, err := req.RouteParameters().NestedValue("dep")
+		if err != nil {
+			return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('dep'): %v", err))
+		}
+		c = &clientConfig.Client{}
+		if err := kv.Interface(c); err != nil {
+			return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+		}
+		c.UrlFunc(clientConfig.Url)
+	}
+
+	err = h.manager.WaitReady(id, c, time.Duration(timeout)*time.Millisecond)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.WaitReady('%s'): %v", id, err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onHealthStatus returns the health of the running dependency by the health monitor.
+// Requires 'id' string parameter.
+//
+// Returns 'status' of the dep_manager.HealthStatus type.
+func (h *DepHandler) onHealthStatus(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	status := h.manager.HealthStatus(id)
+	kv, err := key_value.NewFromInterface(status)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(status): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("status", kv))
+}
+
+// onStopDep stops the dependency spawned by the dep manager.
+// The dependency that doesn't close is terminated, then killed.
+// Requires 'id' string parameter.
+//
+// Returns 'exit' of the dep_manager.ExitStatus type.
+func (h *DepHandler) onStopDep(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	status, err := h.manager.Stop(id)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Stop('%s'): %v", id, err), err)
+	}
+
+	kv, err := key_value.NewFromInterface(status)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(status): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("exit", kv))
+}
+
+// onListDeps returns the running dependencies.
+//
+// Returns 'deps' list of the dep_manager.DepInfo type.
+func (h *DepHandler) onListDeps(req message.RequestInterface) message.ReplyInterface {
+	infos := h.manager.List()
+	deps := make([]key_value.KeyValue, len(infos))
+	for i, info := range infos {
+		kv, err := key_value.NewFromInterface(info)
+		if err != nil {
+			return req.Fail(fmt.Sprintf("key_value.NewFromInterface(info='%s'): %v", info.Id, err))
+		}
+		deps[i] = kv
+	}
+
+	return req.Ok(key_value.New().Set("deps", deps))
+}
+
+// onDepInfo returns the running dependency.
+// Requires 'id' string parameter.
+//
+// Returns 'dep' of the dep_manager.DepInfo type.
+func (h *DepHandler) onDepInfo(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	info, err := h.manager.Info(id)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Info('%s'): %v", id, err), err)
+	}
+
+	kv, err := key_value.NewFromInterface(info)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(info): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("dep", kv))
+}
+
+// onDepLogs returns the output of the dependency.
+// Requires 'id' string parameter, and one of:
+//   - 'lines' number parameter to get the last lines.
+//   - 'offset' number parameter to get the lines written after the offset. Used to follow the output.
+//
+// Returns 'lines' list of strings, and the 'offset' number to read the next lines from.
+func (h *DepHandler) onDepLogs(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	if req.RouteParameters().Exist("offset") {
+		offset, err := req.RouteParameters().Uint64Value("offset")
+		if err != nil {
+			return req.Fail(fmt.Sprintf("req.Parameters.GetUint64('offset'): %v", err))
+		}
+
+		lines, next, err := h.manager.LogsFrom(id, int64(offset))
+		if err != nil {
+			return fail(req, fmt.Sprintf("h.manager.LogsFrom('%s', %d): %v", id, offset, err), err)
+		}
+
+		return req.Ok(key_value.New().Set("lines", lines).Set("offset", uint64(next)))
+	}
+
+	amount, err := req.RouteParameters().Uint64Value("lines")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetUint64('lines'): %v", err))
+	}
+
+	lines, err := h.manager.Logs(id, int(amount))
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.Logs('%s', %d): %v", id, amount, err), err)
+	}
+
+	return req.Ok(key_value.New().Set("lines", lines))
+}
+
+// onUpdateLock sets the locked commits to the heads of their branches in the remote repositories.
+// Requires 'url' string parameter, optionally. If it's not given, then all dependencies are updated.
+// The lookups are limited by the UpdateLockTimeout.
+//
+// Returns 'entries' list of the updated dep_manager.LockEntry type.
+func (h *DepHandler) onUpdateLock(req message.RequestInterface) message.ReplyInterface {
+	optionalUrl, _ := req.RouteParameters().StringValue("url")
+
+	ctx, cancel := context.WithTimeout(context.Background(), UpdateLockTimeout)
+	defer cancel()
+	updated, err := h.manager.UpdateLock(ctx, optionalUrl)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.UpdateLock('%s'): %v", optionalUrl, err), err)
+	}
+
+	entries := make([]key_value.KeyValue, len(updated))
+	for i, entry := range updated {
+		kv, err := key_value.NewFromInterface(entry)
+		if err != nil {
+			return req.Fail(fmt.Sprintf("key_value.NewFromInterface(entry): %v", err))
+		}
+		entries[i] = kv
+	}
+
+	return req.Ok(key_value.New().Set("entries", entries))
+}
+
+// onCrashReports returns the last exits of the dependency, the oldest first.
+// Requires 'id' string parameter.
+//
+// Returns 'reports' list of the dep_manager.CrashReport type.
+func (h *DepHandler) onCrashReports(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	crashes := h.manager.CrashReports(id)
+	reports := make([]key_value.KeyValue, len(crashes))
+	for i, crash := range crashes {
+		kv, err := key_value.NewFromInterface(crash)
+		if err != nil {
+			return req.Fail(fmt.Sprintf("key_value.NewFromInterface(crash): %v", err))
+		}
+		reports[i] = kv
+	}
+
+	return req.Ok(key_value.New().Set("reports", reports))
+}
+
+// onSetRestartPolicy sets the policy to restart the dependency when it exits.
+// Requires:
+//   - 'id' string parameter.
+//   - 'policy' of the dep_manager.RestartPolicy type, optionally. If it's not given, then the policy is removed.
+//
+// Returns nothing.
+func (h *DepHandler) onSetRestartPolicy(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	var policy *dep_manager.RestartPolicy
+	if req.RouteParameters().Exist("policy") {
+		kv, err := req.RouteParameters().NestedValue("policy")
+		if err != nil {
+			return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('policy'): %v", err))
+		}
+
+		policy = &dep_manager.RestartPolicy{}
+		err = kv.Interface(policy)
+		if err != nil {
+			return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+		}
+	}
+
+	err = h.manager.SetRestartPolicy(id, policy)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.SetRestartPolicy('%s'): %v", id, err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onSetBuildMode sets how the modules of the dependency are treated during the build.
+// Requires:
+//   - 'url' string parameter.
+//   - 'mode' string parameter, optionally. One of dep_manager.BuildMode. If it's not given, then the default is used.
+//
+// Returns nothing.
+func (h *DepHandler) onSetBuildMode(req message.RequestInterface) message.ReplyInterface {
+	url, err := req.RouteParameters().StringValue("url")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('url'): %v", err))
+	}
+	optionalMode, _ := req.RouteParameters().StringValue("mode")
+
+	err = h.manager.SetBuildMode(url, optionalMode)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.SetBuildMode('%s', '%s'): %v", url, optionalMode, err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onRestartStatus returns the restarts of the dependency.
+// Requires 'id' string parameter.
+//
+// Returns 'status' of the dep_manager.RestartStatus type.
+func (h *DepHandler) onRestartStatus(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	status := h.manager.RestartStatus(id)
+	kv, err := key_value.NewFromInterface(status)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(status): %v", err))
+	}
+
+	params := key_value.New().Set("status", kv)
+	return req.Ok(params)
+}
+
+// onSetDepGroup adds the group of the dependencies that are restarted together.
+// Requires 'group' of the dep_manager.Group type.
+//
+// Returns nothing.
+func (h *DepHandler) onSetDepGroup(req message.RequestInterface) message.ReplyInterface {
+	kv, err := req.RouteParameters().NestedValue("group")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('group'): %v", err))
+	}
+
+	var group dep_manager.Group
+	err = kv.Interface(&group)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+	}
+
+	err = h.manager.SetGroup(&group)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.SetGroup('%s'): %v", group.Id, err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onRemoveDepGroup deletes the group of the dependencies.
+// The members are not stopped.
+// Requires 'id' string parameter.
+//
+// Returns nothing.
+func (h *DepHandler) onRemoveDepGroup(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	h.manager.RemoveGroup(id)
+
+	return req.Ok(key_value.New())
+}
+
+// onSetDepGraph sets the dependencies between the dependencies used by RunDepGraph and StopDepGraph.
+// Requires 'graph' of the dep_manager.Graph type.
+//
+// Returns nothing.
+func (h *DepHandler) onSetDepGraph(req message.RequestInterface) message.ReplyInterface {
+	kv, err := req.RouteParameters().NestedValue("graph")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('graph'): %v", err))
+	}
+
+	var graph dep_manager.Graph
+	err = kv.Interface(&graph)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+	}
+	if graph.Requires == nil {
+		graph.Requires = make(map[string][]string)
+	}
+
+	err = h.manager.SetGraph(&graph)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.SetGraph: %v", err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onDepGraph returns the start order of the graph.
+//
+// Returns 'graph' of the dep_manager.ResolvedGraph type, or nothing if the graph is not set.
+func (h *DepHandler) onDepGraph(req message.RequestInterface) message.ReplyInterface {
+	resolved := h.manager.Graph()
+	if resolved == nil {
+		return req.Ok(key_value.New())
+	}
+
+	kv, err := key_value.NewFromInterface(resolved)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(graph): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("graph", kv))
+}
+
+// onRunDepGraph runs the dependencies in the order of the graph.
+// If any dependency fails, then the started ones are stopped.
+// Requires:
+//   - 'deps' list of the GraphDep type,
+//   - 'parent' of the clientConfig.Client type.
+//
+// Returns nothing.
+func (h *DepHandler) onRunDepGraph(req message.RequestInterface) message.ReplyInterface {
+	kv, err := req.RouteParameters().NestedValue("parent")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetKeyValue('parent'): %v", err))
+	}
+
+	var parent clientConfig.Client
+	err = kv.Interface(&parent)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("kv.Interface: %v", err))
+	}
+
+	parent.UrlFunc(clientConfig.Url)
+
+	kvs, err := req.RouteParameters().NestedListValue("deps")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.NestedListValue('deps'): %v", err))
+	}
+
+	deps := make(map[string]*dep_manager.Dep, len(kvs))
+	for i, kv := range kvs {
+		var graphDep GraphDep
+		if err := kv.Interface(&graphDep); err != nil {
+			return req.Fail(fmt.Sprintf("kvs[%d].Interface: %v", i, err))
+		}
+
+		dep, err := graphDep.dep()
+		if err != nil {
+			return req.Fail(fmt.Sprintf("deps[%d]: %v", i, err))
+		}
+		h.manager.Lint(dep)
+		deps[graphDep.Id] = dep
+	}
+
+	err = h.manager.RunGraph(deps, &parent)
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.RunGraph: %v", err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onStopDepGraph stops the running dependencies of the graph in the reverse start order.
+//
+// Returns nothing.
+func (h *DepHandler) onStopDepGraph(req message.RequestInterface) message.ReplyInterface {
+	err := h.manager.StopGraph()
+	if err != nil {
+		return fail(req, fmt.Sprintf("h.manager.StopGraph: %v", err), err)
+	}
+
+	return req.Ok(key_value.New())
+}
+
+// onJobStatus returns the asynchronous install, update or run.
+// Requires 'id' string parameter of the job.
+//
+// Returns 'job' of the Job type.
+func (h *DepHandler) onJobStatus(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	job, ok := h.jobs.get(id)
+	if !ok {
+		err := errNoJob(id)
+		return fail(req, err.Error(), err)
+	}
+
+	kv, err := key_value.NewFromInterface(job)
+	if err != nil {
+		return req.Fail(fmt.Sprintf("key_value.NewFromInterface(job): %v", err))
+	}
+
+	return req.Ok(key_value.New().Set("job", kv))
+}
+
+// onCancelJob cancels the asynchronous install, update or run.
+// The queued job fails at once. The running installation is stopped.
+// Requires 'id' string parameter of the job.
+//
+// Returns nothing.
+func (h *DepHandler) onCancelJob(req message.RequestInterface) message.ReplyInterface {
+	id, err := req.RouteParameters().StringValue("id")
+	if err != nil {
+		return req.Fail(fmt.Sprintf("req.Parameters.GetString('id'): %v", err))
+	}
+
+	if err := h.jobs.cancel(id); err != nil {
+		return fail(req, fmt.Sprintf("h.jobs.cancel: %v", err), err)
 	}
 
 	return req.Ok(key_value.New())
 }
 
 // Start the dependency handler with the available operations.
+// The progress of the asynchronous jobs is published to the ProgressUrl.
 func (h *DepHandler) Start() error {
+	publisher, err := startPublisher()
+	if err != nil {
+		return fmt.Errorf("startPublisher: %w", err)
+	}
+	h.jobs.publisher = publisher
+
 	if err := h.handler.Route(DepInstalled, h.onDepInstalled); err != nil {
 		return fmt.Errorf("h.handler.Route('%s'): %v", DepInstalled, err)
 	}
 	if err := h.handler.Route(DepRunning, h.onDepRunning); err != nil {
 		return fmt.Errorf("h.handler.Route('%s'): %v", DepRunning, err)
 	}
+	if err := h.handler.Route(ProbeDep, h.onProbeDep); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", ProbeDep, err)
+	}
 	if err := h.handler.Route(InstallDep, h.onInstallDep); err != nil {
 		return fmt.Errorf("h.handler.Route('%s'): %v", InstallDep, err)
 	}
@@ -278,6 +994,69 @@ func (h *DepHandler) Start() error {
 	if err := h.handler.Route(CloseDep, h.onCloseDep); err != nil {
 		return fmt.Errorf("h.handler.Route('%s'): %v", CloseDep, err)
 	}
+	if err := h.handler.Route(WaitReady, h.onWaitReady); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", WaitReady, err)
+	}
+	if err := h.handler.Route(HealthStatus, h.onHealthStatus); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", HealthStatus, err)
+	}
+	if err := h.handler.Route(StopDep, h.onStopDep); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", StopDep, err)
+	}
+	if err := h.handler.Route(ListDeps, h.onListDeps); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", ListDeps, err)
+	}
+	if err := h.handler.Route(DepInfo, h.onDepInfo); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", DepInfo, err)
+	}
+	if err := h.handler.Route(DepLogs, h.onDepLogs); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", DepLogs, err)
+	}
+	if err := h.handler.Route(UpdateDep, h.onUpdateDep); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", UpdateDep, err)
+	}
+	if err := h.handler.Route(RollbackDep, h.onRollbackDep); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", RollbackDep, err)
+	}
+	if err := h.handler.Route(UpdateLock, h.onUpdateLock); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", UpdateLock, err)
+	}
+	if err := h.handler.Route(CrashReports, h.onCrashReports); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", CrashReports, err)
+	}
+	if err := h.handler.Route(SetRestartPolicy, h.onSetRestartPolicy); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", SetRestartPolicy, err)
+	}
+	if err := h.handler.Route(SetBuildMode, h.onSetBuildMode); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", SetBuildMode, err)
+	}
+	if err := h.handler.Route(RestartStatus, h.onRestartStatus); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", RestartStatus, err)
+	}
+	if err := h.handler.Route(SetDepGroup, h.onSetDepGroup); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", SetDepGroup, err)
+	}
+	if err := h.handler.Route(RemoveDepGroup, h.onRemoveDepGroup); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", RemoveDepGroup, err)
+	}
+	if err := h.handler.Route(SetDepGraph, h.onSetDepGraph); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", SetDepGraph, err)
+	}
+	if err := h.handler.Route(DepGraph, h.onDepGraph); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", DepGraph, err)
+	}
+	if err := h.handler.Route(RunDepGraph, h.onRunDepGraph); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", RunDepGraph, err)
+	}
+	if err := h.handler.Route(StopDepGraph, h.onStopDepGraph); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", StopDepGraph, err)
+	}
+	if err := h.handler.Route(JobStatus, h.onJobStatus); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", JobStatus, err)
+	}
+	if err := h.handler.Route(CancelJob, h.onCancelJob); err != nil {
+		return fmt.Errorf("h.handler.Route('%s'): %v", CancelJob, err)
+	}
 
 	return h.handler.Start()
 }
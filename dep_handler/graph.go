@@ -0,0 +1,40 @@
+package dep_handler
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"time"
+)
+
+// A GraphDep is the dependency run by the RunDepGraph command.
+// Its order is set by the SetDepGraph command.
+type GraphDep struct {
+	Id       string               `json:"id"`
+	Url      string               `json:"url"`
+	LocalBin string               `json:"local_bin,omitempty"`
+	Manager  *clientConfig.Client `json:"manager,omitempty"` // the socket of the dependency itself
+	// ReadyTimeout in milliseconds to wait for the heartbeat of the dependency before starting the ones that require it.
+	// Requires the Manager.
+	ReadyTimeout uint64 `json:"ready_timeout,omitempty"`
+}
+
+// The dep returns the dependency to run. It's not linted.
+func (graphDep *GraphDep) dep() (*dep_manager.Dep, error) {
+	if len(graphDep.Id) == 0 {
+		return nil, fmt.Errorf("no id of '%s'", graphDep.Url)
+	}
+
+	dep, err := dep_manager.NewDep(graphDep.Url, "", graphDep.LocalBin)
+	if err != nil {
+		return nil, fmt.Errorf("dep_manager.NewDep('%s', '', '%s'): %w", graphDep.Url, graphDep.LocalBin, err)
+	}
+
+	if graphDep.Manager != nil {
+		graphDep.Manager.UrlFunc(clientConfig.Url)
+		dep.SetManager(graphDep.Manager)
+	}
+	dep.SetReadyTimeout(time.Duration(graphDep.ReadyTimeout) * time.Millisecond)
+
+	return dep, nil
+}
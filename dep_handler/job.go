@@ -0,0 +1,257 @@
+package dep_handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"sync"
+	"time"
+)
+
+// JobState is the state of the asynchronous install, update or run
+type JobState = string
+
+const (
+	JobQueued   JobState = "queued"                  // waits for the free worker
+	JobCloning  JobState = dep_manager.StageCloning  // downloads the source code
+	JobTidying  JobState = dep_manager.StageTidying  // updates the modules
+	JobBuilding JobState = dep_manager.StageBuilding // builds the binary
+	JobDone     JobState = "done"                    // finished successfully
+	JobFailed   JobState = "failed"                  // finished with an error, or canceled
+)
+
+const (
+	// jobWorkers is the amount of the jobs running at the same time
+	jobWorkers = 4
+	// maxFinishedJobs is the amount of the finished jobs kept in the table for the status requests
+	maxFinishedJobs = 100
+)
+
+// ErrJobCanceled is the error of the job canceled by the user.
+// It's the dep_manager.ErrCanceled kind, so the clients could check it by the code.
+var ErrJobCanceled error = &dep_manager.Error{Kind: dep_manager.ErrCanceled, Err: errors.New("job canceled")}
+
+// errNoJob returns the error of the job that is not in the table
+func errNoJob(id string) error {
+	return &dep_manager.Error{Kind: dep_manager.ErrNotFound, Err: fmt.Errorf("no '%s' job", id)}
+}
+
+// A Job is the asynchronous install, update or run of the dependency
+type Job struct {
+	Id        string    `json:"id"`
+	Command   string    `json:"command"` // InstallDep, UpdateDep or RunDep
+	Url       string    `json:"url"`
+	State     JobState  `json:"state"`
+	Error     string    `json:"error,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"` // the code of the dep_manager error
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// IsFinished returns true if the job is done or failed
+func (job *Job) IsFinished() bool {
+	return job.State == JobDone || job.State == JobFailed
+}
+
+// The jobTable keeps the jobs of the dep handler
+type jobTable struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	finished  []string // the finished job ids in the finishing order
+	next      uint64
+	workers   chan struct{}
+	publisher *publisher
+}
+
+func newJobTable() *jobTable {
+	return &jobTable{
+		jobs:     make(map[string]*Job),
+		finished: make([]string, 0),
+		workers:  make(chan struct{}, jobWorkers),
+	}
+}
+
+// add the queued job
+func (t *jobTable) add(command string, url string) *Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		Id:      fmt.Sprintf("job-%d", t.next),
+		Command: command,
+		Url:     url,
+		State:   JobQueued,
+		Created: now,
+		Updated: now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	t.jobs[job.Id] = job
+
+	return job
+}
+
+// get returns the copy of the job
+func (t *jobTable) get(id string) (Job, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	job, ok := t.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+
+	return *job, true
+}
+
+// cancel the job. The queued job fails at once.
+// The running installation or update is stopped, the other running jobs fail before their next stage.
+func (t *jobTable) cancel(id string) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	job, ok := t.jobs[id]
+	if !ok {
+		return errNoJob(id)
+	}
+	if job.IsFinished() {
+		return fmt.Errorf("the '%s' job is %s already", id, job.State)
+	}
+	job.cancel()
+
+	return nil
+}
+
+// setState changes the state of the running job and publishes it.
+// Returns ErrJobCanceled if the job was canceled.
+func (t *jobTable) setState(id string, state JobState) error {
+	t.mu.Lock()
+	job := t.jobs[id]
+	if job.ctx.Err() != nil {
+		t.mu.Unlock()
+		return ErrJobCanceled
+	}
+	updated := time.Now()
+	job.State = state
+	job.Updated = updated
+	t.mu.Unlock()
+
+	t.publisher.publish(&Progress{JobId: id, State: state, Time: updated})
+	return nil
+}
+
+// finish marks the job as done or failed, and publishes the final state
+func (t *jobTable) finish(id string, err error) {
+	t.mu.Lock()
+	job := t.jobs[id]
+	job.State = JobDone
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+		job.ErrorCode = dep_manager.ErrorCode(err)
+	}
+	job.Updated = time.Now()
+	job.cancel()
+	progress := &Progress{JobId: id, State: job.State, Line: job.Error, Time: job.Updated}
+
+	t.finished = append(t.finished, id)
+	for len(t.finished) > maxFinishedJobs {
+		delete(t.jobs, t.finished[0])
+		t.finished = t.finished[1:]
+	}
+	t.mu.Unlock()
+
+	t.publisher.publish(progress)
+}
+
+// line publishes the output line of the job
+func (t *jobTable) line(id string, line string) {
+	t.mu.RLock()
+	state := t.jobs[id].State
+	t.mu.RUnlock()
+
+	t.publisher.publish(&Progress{JobId: id, State: state, Line: line, Time: time.Now()})
+}
+
+// run the job in the background, when the worker is free.
+// The context passed to the function is done when the job is canceled.
+func (t *jobTable) run(job *Job, fn func(ctx context.Context, progress *jobProgress) error) {
+	go func() {
+		select {
+		case t.workers <- struct{}{}:
+		case <-job.ctx.Done():
+			t.finish(job.Id, ErrJobCanceled)
+			return
+		}
+		defer func() {
+			<-t.workers
+		}()
+
+		if job.ctx.Err() != nil {
+			t.finish(job.Id, ErrJobCanceled)
+			return
+		}
+
+		progress := &jobProgress{table: t, jobId: job.Id}
+		err := fn(job.ctx, progress)
+		progress.flush()
+		if err != nil && errors.Is(err, context.Canceled) {
+			err = fmt.Errorf("%w: %v", ErrJobCanceled, err)
+		}
+		t.finish(job.Id, err)
+	}()
+}
+
+// The jobProgress passes the stages and the output of the installation to the job table.
+// It implements dep_manager.Progress.
+type jobProgress struct {
+	table *jobTable
+	jobId string
+	mu    sync.Mutex
+	buf   []byte
+}
+
+// Stage changes the state of the job. If the job was canceled, then the update stops,
+// and the installation shared with the other jobs stops notifying this job.
+func (p *jobProgress) Stage(stage dep_manager.Stage) error {
+	return p.table.setState(p.jobId, stage)
+}
+
+// Write publishes the output line by line
+func (p *jobProgress) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexAny(p.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			p.table.line(p.jobId, string(p.buf[:i]))
+		}
+		p.buf = p.buf[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// flush publishes the last line without the line break
+func (p *jobProgress) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		p.table.line(p.jobId, string(p.buf))
+		p.buf = nil
+	}
+}
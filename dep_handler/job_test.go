@@ -0,0 +1,168 @@
+package dep_handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestJobSuite struct {
+	suite.Suite
+
+	jobs *jobTable
+}
+
+func (test *TestJobSuite) SetupTest() {
+	test.jobs = newJobTable()
+}
+
+// waitJob waits until the job is finished
+func (test *TestJobSuite) waitJob(id string) Job {
+	s := test.Require
+
+	for i := 0; i < 100; i++ {
+		job, ok := test.jobs.get(id)
+		s().True(ok)
+		if job.IsFinished() {
+			return job
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	s().Fail("the job is not finished")
+	return Job{}
+}
+
+// Test_10_Run tests the states of the successful and failed jobs
+func (test *TestJobSuite) Test_10_Run() {
+	s := test.Require
+
+	job := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+	s().Equal("job-1", job.Id)
+	s().Equal(JobQueued, job.State)
+
+	stages := make(chan JobState, 3)
+	test.jobs.run(job, func(_ context.Context, progress *jobProgress) error {
+		for _, stage := range []dep_manager.Stage{dep_manager.StageCloning, dep_manager.StageTidying, dep_manager.StageBuilding} {
+			test.Assert().NoError(progress.Stage(stage))
+			current, _ := test.jobs.get(job.Id)
+			stages <- current.State
+		}
+		return nil
+	})
+
+	finished := test.waitJob(job.Id)
+	s().Equal(JobDone, finished.State)
+	s().Empty(finished.Error)
+	s().Equal(JobCloning, <-stages)
+	s().Equal(JobTidying, <-stages)
+	s().Equal(JobBuilding, <-stages)
+
+	// the failed job keeps the error code
+	job = test.jobs.add(RunDep, "github.com/ahmetson/test-manager")
+	test.jobs.run(job, func(context.Context, *jobProgress) error {
+		return fmt.Errorf("h.manager.Run: %w", dep_manager.ErrNotInstalled)
+	})
+
+	finished = test.waitJob(job.Id)
+	s().Equal(JobFailed, finished.State)
+	s().Equal(dep_manager.ErrorCode(dep_manager.ErrNotInstalled), finished.ErrorCode)
+
+	// the finished job can't be canceled
+	s().Error(test.jobs.cancel(job.Id))
+	s().Error(test.jobs.cancel("no-job"))
+}
+
+// Test_11_Cancel tests canceling the running and queued jobs
+func (test *TestJobSuite) Test_11_Cancel() {
+	s := test.Require
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+	test.jobs.run(job, func(_ context.Context, progress *jobProgress) error {
+		close(started)
+		<-release
+		// the canceled job stops at the next stage
+		return progress.Stage(dep_manager.StageBuilding)
+	})
+
+	// the running job is stopped by its context
+	stopped := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+	stoppedStarted := make(chan struct{})
+	test.jobs.run(stopped, func(ctx context.Context, _ *jobProgress) error {
+		close(stoppedStarted)
+		<-ctx.Done()
+		return fmt.Errorf("git.PlainCloneContext: %w", ctx.Err())
+	})
+	<-started
+	<-stoppedStarted
+
+	// occupy all workers, so the next job is queued
+	for i := 2; i < jobWorkers; i++ {
+		busy := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+		test.jobs.run(busy, func(context.Context, *jobProgress) error {
+			<-release
+			return nil
+		})
+	}
+	queued := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+	test.jobs.run(queued, func(context.Context, *jobProgress) error {
+		return nil
+	})
+
+	s().NoError(test.jobs.cancel(queued.Id))
+	finished := test.waitJob(queued.Id)
+	s().Equal(JobFailed, finished.State)
+	s().Equal(ErrJobCanceled.Error(), finished.Error)
+	s().Equal(dep_manager.ErrorCode(dep_manager.ErrCanceled), finished.ErrorCode)
+
+	s().NoError(test.jobs.cancel(stopped.Id))
+	finished = test.waitJob(stopped.Id)
+	s().Equal(JobFailed, finished.State)
+	s().Contains(finished.Error, ErrJobCanceled.Error())
+	s().Equal(dep_manager.ErrorCode(dep_manager.ErrCanceled), finished.ErrorCode)
+
+	s().NoError(test.jobs.cancel(job.Id))
+	close(release)
+	finished = test.waitJob(job.Id)
+	s().Equal(JobFailed, finished.State)
+	s().Contains(finished.Error, ErrJobCanceled.Error())
+
+	// the unknown and finished jobs are not canceled
+	s().ErrorIs(test.jobs.cancel("unknown"), dep_manager.ErrNotFound)
+	s().Error(test.jobs.cancel(job.Id))
+}
+
+// Test_12_Write tests splitting the output into the lines
+func (test *TestJobSuite) Test_12_Write() {
+	s := test.Require
+
+	job := test.jobs.add(InstallDep, "github.com/ahmetson/test-manager")
+	progress := &jobProgress{table: test.jobs, jobId: job.Id}
+
+	n, err := progress.Write([]byte("first\nsec"))
+	s().NoError(err)
+	s().Equal(9, n)
+	s().Equal("sec", string(progress.buf))
+
+	_, err = progress.Write([]byte("ond\r\n"))
+	s().NoError(err)
+	s().Empty(progress.buf)
+
+	_, err = progress.Write([]byte("last"))
+	s().NoError(err)
+	progress.flush()
+	s().Empty(progress.buf)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestJob(t *testing.T) {
+	suite.Run(t, new(TestJobSuite))
+}
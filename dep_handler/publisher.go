@@ -0,0 +1,100 @@
+package dep_handler
+
+import (
+	"encoding/json"
+	"fmt"
+	handlerConfig "github.com/ahmetson/handler-lib/config"
+	zmq "github.com/pebbe/zmq4"
+	"sync"
+	"time"
+)
+
+// publisherBuffer is the amount of the progress messages waiting to be published.
+// If the publisher is slower than the jobs, then the new messages are dropped.
+const publisherBuffer = 1024
+
+// A Progress is the message published for the subscribers of the job
+type Progress struct {
+	JobId string    `json:"job_id"`
+	State JobState  `json:"state"`
+	Line  string    `json:"line,omitempty"` // the output line of the clone or build, empty on the state change
+	Time  time.Time `json:"time"`
+}
+
+// The publisher streams the progress of the jobs.
+// The zmq sockets are not thread-safe, so the socket is used by a single goroutine.
+type publisher struct {
+	messages chan []string
+}
+
+var (
+	pubOnce sync.Once
+	pub     *publisher
+	pubErr  error
+)
+
+// ProgressUrl returns the url of the socket that publishes the Progress of the jobs.
+// The topic of the message is the job id, so the subscriber could subscribe to the job only.
+func ProgressUrl() string {
+	return handlerConfig.ExternalUrl(Category+"_progress", 0)
+}
+
+// ParseProgress decodes the messages received by the subscriber
+func ParseProgress(messages []string) (*Progress, error) {
+	if len(messages) != 2 {
+		return nil, fmt.Errorf("expected topic and progress, got %d messages", len(messages))
+	}
+
+	var progress Progress
+	if err := json.Unmarshal([]byte(messages[1]), &progress); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return &progress, nil
+}
+
+// startPublisher binds the publisher socket.
+// The url is shared by the process, so the publisher is started once and shared by all dep handlers.
+func startPublisher() (*publisher, error) {
+	pubOnce.Do(func() {
+		socket, err := zmq.NewSocket(zmq.PUB)
+		if err != nil {
+			pubErr = fmt.Errorf("zmq.NewSocket: %w", err)
+			return
+		}
+		if err := socket.Bind(ProgressUrl()); err != nil {
+			_ = socket.Close()
+			pubErr = fmt.Errorf("socket.Bind('%s'): %w", ProgressUrl(), err)
+			return
+		}
+
+		pub = &publisher{messages: make(chan []string, publisherBuffer)}
+		go pub.loop(socket)
+	})
+
+	return pub, pubErr
+}
+
+// loop sends the messages through the socket
+func (p *publisher) loop(socket *zmq.Socket) {
+	for messages := range p.messages {
+		_, _ = socket.SendMessageDontwait(messages)
+	}
+}
+
+// publish the progress without blocking
+func (p *publisher) publish(progress *Progress) {
+	if p == nil {
+		return
+	}
+
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+
+	select {
+	case p.messages <- []string{progress.JobId, string(data)}:
+	default:
+	}
+}
@@ -0,0 +1,110 @@
+package dep_manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuildMode defines how the build treats the go.mod and go.sum of the source code
+type BuildMode = string
+
+const (
+	BuildTidy     BuildMode = "tidy"     // 'go mod tidy' updates the modules before the build
+	BuildReadonly BuildMode = "readonly" // the build fails if the modules are out of date
+	BuildVendor   BuildMode = "vendor"   // the modules are taken from the vendor directory
+)
+
+// buildOutputLines is the amount of the last build error lines checked for the outdated modules
+const buildOutputLines = 50
+
+// outdatedModules are the 'go build' errors of the go.mod, go.sum or vendor directory that are out of date
+var outdatedModules = []string{
+	"updates to go.mod needed",
+	"missing go.sum entry",
+	"no required module provides package",
+	"cannot find module providing package",
+	"disabled by -mod=",
+	"inconsistent vendoring",
+	"vendor/modules.txt",
+}
+
+// IsValidBuildMode returns an error if the build mode is not known.
+// The empty build mode is the default one.
+func IsValidBuildMode(mode BuildMode) error {
+	switch mode {
+	case "", BuildTidy, BuildReadonly, BuildVendor:
+		return nil
+	}
+
+	return fmt.Errorf("unknown '%s' build mode, expected '%s', '%s' or '%s'", mode, BuildTidy, BuildReadonly, BuildVendor)
+}
+
+// SetBuildMode sets the build mode of the dependency by its url.
+// Pass the empty mode to use the default one.
+//
+// By default, the source code downloaded by the DepManager is tidied,
+// while the local source code is built in the BuildReadonly mode, so its go.mod and go.sum are never changed.
+// The BuildTidy mode can't be used for the local source code.
+func (manager *DepManager) SetBuildMode(url string, mode BuildMode) error {
+	if manager == nil || len(url) == 0 {
+		return fmt.Errorf("nil or no url")
+	}
+	if err := IsValidBuildMode(mode); err != nil {
+		return fmt.Errorf("IsValidBuildMode: %w", err)
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if len(mode) == 0 {
+		delete(manager.buildModes, url)
+		return nil
+	}
+	manager.buildModes[url] = mode
+
+	return nil
+}
+
+// BuildMode returns the build mode of the dependency
+func (manager *DepManager) BuildMode(dep *Dep) BuildMode {
+	manager.mu.RLock()
+	mode, ok := manager.buildModes[dep.Url]
+	manager.mu.RUnlock()
+	if ok {
+		return mode
+	}
+
+	if dep.manageableSrc {
+		return BuildTidy
+	}
+	return BuildReadonly
+}
+
+// buildFlags returns the flags of 'go build' except the output path.
+// They are recorded in the lockfile.
+func (manager *DepManager) buildFlags(dep *Dep) []string {
+	switch manager.BuildMode(dep) {
+	case BuildReadonly:
+		return []string{"-mod=readonly"}
+	case BuildVendor:
+		return []string{"-mod=vendor"}
+	}
+
+	// the tidied modules are always in sync
+	return []string{}
+}
+
+// modulesError returns ErrModulesOutdated if the build output tells that the modules are out of date.
+// Returns nil for the other build errors.
+func modulesError(srcPath string, mode BuildMode, output []string) error {
+	for _, line := range output {
+		for _, outdated := range outdatedModules {
+			if strings.Contains(line, outdated) {
+				return newError(ErrModulesOutdated, fmt.Errorf("the modules of '%s' are out of date for the '%s' build mode: %s. "+
+					"Update them with 'go mod tidy' or 'go mod vendor'", srcPath, mode, strings.TrimSpace(line)))
+			}
+		}
+	}
+
+	return nil
+}
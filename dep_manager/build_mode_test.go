@@ -0,0 +1,95 @@
+package dep_manager
+
+import (
+	"context"
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/ahmetson/log-lib"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestBuildModeSuite struct {
+	suite.Suite
+
+	logger     *log.Logger
+	depManager *DepManager
+	dep        *Dep // the dependency with the local source code
+	goMod      string
+}
+
+// SetupTest creates the local source code that imports the package missing in its go.mod
+func (test *TestBuildModeSuite) SetupTest() {
+	s := test.Require
+
+	logger, err := log.New("TestBuildModeSuite", false)
+	s().NoError(err)
+	test.logger = logger
+
+	srcPath := test.T().TempDir()
+	test.goMod = "module example.com/app\n\ngo 1.19\n"
+	s().NoError(os.WriteFile(filepath.Join(srcPath, "go.mod"), []byte(test.goMod), 0644))
+	main := "package main\n\nimport _ \"github.com/ahmetson/unknown-lib\"\n\nfunc main() {}\n"
+	s().NoError(os.WriteFile(filepath.Join(srcPath, "main.go"), []byte(main), 0644))
+
+	test.depManager = New()
+	test.dep = &Dep{
+		Src:           &source.Src{Url: "example.com/app"},
+		srcPath:       srcPath,
+		binPath:       filepath.Join(test.T().TempDir(), "example.com.app"),
+		manageableBin: true,
+	}
+}
+
+// Test_10_BuildMode tests the default build modes and their flags
+func (test *TestBuildModeSuite) Test_10_BuildMode() {
+	s := test.Require
+
+	s().Equal(BuildReadonly, test.depManager.BuildMode(test.dep))
+	s().Equal([]string{"-mod=readonly"}, test.depManager.buildFlags(test.dep))
+
+	managed := &Dep{Src: &source.Src{Url: "github.com/ahmetson/test-manager"}, manageableSrc: true}
+	s().Equal(BuildTidy, test.depManager.BuildMode(managed))
+	s().Empty(test.depManager.buildFlags(managed))
+
+	s().Error(test.depManager.SetBuildMode(managed.Url, "mod"))
+	s().Error(test.depManager.SetBuildMode("", BuildVendor))
+	s().NoError(test.depManager.SetBuildMode(managed.Url, BuildVendor))
+	s().Equal([]string{"-mod=vendor"}, test.depManager.buildFlags(managed))
+
+	// back to the default
+	s().NoError(test.depManager.SetBuildMode(managed.Url, ""))
+	s().Equal(BuildTidy, test.depManager.BuildMode(managed))
+}
+
+// Test_11_Readonly tests that the local source code is never tidied
+func (test *TestBuildModeSuite) Test_11_Readonly() {
+	s := test.Require
+
+	err := test.depManager.build(context.Background(), test.dep, test.logger)
+	s().ErrorIs(err, ErrModulesOutdated)
+	s().NotErrorIs(err, ErrBuildFailed)
+
+	data, err := os.ReadFile(filepath.Join(test.dep.srcPath, "go.mod"))
+	s().NoError(err)
+	s().Equal(test.goMod, string(data))
+	_, err = os.Stat(test.dep.binPath)
+	s().True(os.IsNotExist(err))
+
+	s().NoError(test.depManager.SetBuildMode(test.dep.Url, BuildTidy))
+	s().ErrorIs(test.depManager.build(context.Background(), test.dep, test.logger), ErrNotManageable)
+
+	// not the module error
+	s().Nil(modulesError(test.dep.srcPath, BuildReadonly, []string{"./main.go:3:1: syntax error"}))
+	s().ErrorIs(modulesError(test.dep.srcPath, BuildVendor, []string{"go: inconsistent vendoring in /app:"}), ErrModulesOutdated)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestBuildMode(t *testing.T) {
+	suite.Run(t, new(TestBuildModeSuite))
+}
@@ -0,0 +1,162 @@
+package dep_manager
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"sync"
+	"time"
+)
+
+const (
+	// CrashOutputLines is the amount of the last stderr lines kept in the CrashReport
+	CrashOutputLines = 50
+	// MaxCrashReports is the amount of the last exits kept for each dependency
+	MaxCrashReports = 10
+)
+
+// A CrashReport is the record of the dependency exit
+type CrashReport struct {
+	Id      string        `json:"id"`
+	Time    time.Time     `json:"time"`
+	Exit    *ExitStatus   `json:"exit"`
+	Runtime time.Duration `json:"runtime"` // the time since the start until the exit
+	BinPath string        `json:"bin_path"`
+	Args    []string      `json:"args"`
+	Stderr  []string      `json:"stderr"` // the last lines of stderr, empty for the detached dependency
+	Build   *BuildInfo    `json:"build,omitempty"`
+}
+
+// BuildInfo is the version of the dependency binary
+type BuildInfo struct {
+	GoVersion string `json:"go_version"`
+	Path      string `json:"path"`               // the main package
+	Version   string `json:"version"`            // the version of the main module
+	Revision  string `json:"revision,omitempty"` // the commit the binary was built from
+	Modified  bool   `json:"modified"`           // the source code had the uncommitted changes
+}
+
+// readBuildInfo returns the build info embedded into the go binary.
+// Returns nil if the binary has no build info.
+func readBuildInfo(binPath string) *BuildInfo {
+	info, err := buildinfo.ReadFile(binPath)
+	if err != nil {
+		return nil
+	}
+
+	build := &BuildInfo{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Version:   info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+
+	return build
+}
+
+// CrashReports returns the last exits of the dependency by its id, the oldest first.
+// The reports are kept after the dependency is removed from the DepManager.
+func (manager *DepManager) CrashReports(id string) []*CrashReport {
+	if manager == nil {
+		return []*CrashReport{}
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	reports := make([]*CrashReport, len(manager.crashes[id]))
+	copy(reports, manager.crashes[id])
+
+	return reports
+}
+
+// recordCrash adds the report of the exited dependency.
+// The oldest report is deleted if there are more than MaxCrashReports.
+func (manager *DepManager) recordCrash(id string, binPath string, args []string, startTime time.Time, stderr *tailBuffer, exitErr error) {
+	now := time.Now()
+	report := &CrashReport{
+		Id:      id,
+		Time:    now,
+		Exit:    newExitStatus(exitErr),
+		BinPath: binPath,
+		Args:    args,
+		Stderr:  []string{},
+		Build:   readBuildInfo(binPath),
+	}
+	if !startTime.IsZero() {
+		report.Runtime = now.Sub(startTime)
+	}
+	if stderr != nil {
+		report.Stderr = stderr.lines()
+	}
+
+	manager.mu.Lock()
+	reports := append(manager.crashes[id], report)
+	if len(reports) > MaxCrashReports {
+		reports = reports[len(reports)-MaxCrashReports:]
+	}
+	manager.crashes[id] = reports
+	manager.mu.Unlock()
+}
+
+// The tailBuffer keeps the last lines written into it
+type tailBuffer struct {
+	mu      sync.Mutex
+	max     int
+	tail    []string
+	partial []byte // the line without the line break yet
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max, tail: make([]string, 0, max)}
+}
+
+// Write the output, the lines beyond the max are dropped
+func (b *tailBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.partial = append(b.partial, data...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(bytes.TrimRight(b.partial[:i], "\r")))
+		b.partial = b.partial[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// add the line. The caller must lock the buffer.
+func (b *tailBuffer) add(line string) {
+	if len(b.tail) == b.max {
+		copy(b.tail, b.tail[1:])
+		b.tail = b.tail[:b.max-1]
+	}
+	b.tail = append(b.tail, line)
+}
+
+// lines returns the last lines including the unfinished line
+func (b *tailBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := make([]string, len(b.tail), len(b.tail)+1)
+	copy(lines, b.tail)
+	if len(b.partial) > 0 {
+		lines = append(lines, string(b.partial))
+		if len(lines) > b.max {
+			lines = lines[1:]
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,87 @@
+package dep_manager
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestCrashSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestCrashSuite) SetupTest() {
+	test.manager = New()
+}
+
+// Test_10_TailBuffer tests that only the last lines are kept
+func (test *TestCrashSuite) Test_10_TailBuffer() {
+	s := test.Require
+
+	tail := newTailBuffer(2)
+	s().Empty(tail.lines())
+
+	_, err := tail.Write([]byte("first\r\nsecond\nth"))
+	s().NoError(err)
+	s().Equal([]string{"first", "second"}, tail.tail)
+	s().Equal([]string{"second", "th"}, tail.lines())
+
+	_, err = tail.Write([]byte("ird\nfourth\n"))
+	s().NoError(err)
+	s().Equal([]string{"third", "fourth"}, tail.lines())
+}
+
+// Test_11_History tests that the reports are kept up to the limit
+func (test *TestCrashSuite) Test_11_History() {
+	s := test.Require
+
+	s().Empty(test.manager.CrashReports("dep"))
+
+	binPath, err := os.Executable()
+	s().NoError(err)
+
+	stderr := newTailBuffer(CrashOutputLines)
+	_, err = stderr.Write([]byte("panic: with-error\n"))
+	s().NoError(err)
+
+	startTime := time.Now().Add(-time.Minute)
+	for i := 0; i < MaxCrashReports+2; i++ {
+		test.manager.recordCrash("dep", binPath, []string{fmt.Sprintf("--id=%d", i)}, startTime, stderr, fmt.Errorf("exit"))
+	}
+
+	reports := test.manager.CrashReports("dep")
+	s().Len(reports, MaxCrashReports)
+	// the oldest reports are deleted
+	s().Equal([]string{"--id=2"}, reports[0].Args)
+	s().Equal([]string{fmt.Sprintf("--id=%d", MaxCrashReports+1)}, reports[MaxCrashReports-1].Args)
+
+	report := reports[0]
+	s().Equal("dep", report.Id)
+	s().Equal(-1, report.Exit.Code)
+	s().GreaterOrEqual(report.Runtime, time.Minute)
+	s().Equal([]string{"panic: with-error"}, report.Stderr)
+	// the test binary is built by go
+	s().NotNil(report.Build)
+	s().NotEmpty(report.Build.GoVersion)
+
+	// the binary without the build info
+	test.manager.recordCrash("script", "/no/binary", nil, time.Time{}, nil, nil)
+	report = test.manager.CrashReports("script")[0]
+	s().Nil(report.Build)
+	s().Equal(0, report.Exit.Code)
+	s().Zero(report.Runtime)
+	s().Empty(report.Stderr)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestCrash(t *testing.T) {
+	suite.Run(t, new(TestCrashSuite))
+}
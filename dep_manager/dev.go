@@ -2,6 +2,7 @@
 package dep_manager
 
 import (
+	"context"
 	"fmt"
 	"github.com/ahmetson/client-lib"
 	clientConfig "github.com/ahmetson/client-lib/config"
@@ -12,11 +13,13 @@ import (
 	"github.com/ahmetson/os-lib/path"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,24 +27,67 @@ import (
 // DepManager.Running method uses this value before considering the socket as not running.
 const DefaultTimeout = time.Second
 
+// watchInterval is the interval to check whether the adopted dependency is still alive.
+const watchInterval = time.Second
+
 type Dep struct {
 	*source.Src
 
 	srcPath       string
 	binPath       string
 	manageableSrc bool
-	manageableBin bool // if a binary was set by the user, then it's not updatable or deletable
+	manageableBin bool                 // if a binary was set by the user, then it's not updatable or deletable
+	detached      bool                 // run the dependency in its own session, so it survives the DepManager
+	readyTimeout  time.Duration        // the time DepManager.Run waits for the heartbeat, optional
+	manager       *clientConfig.Client // the socket of the dependency, optional
+	progress      Progress             // the receiver of the installation progress, optional
 	cmd           *exec.Cmd
-	done          chan error // signalizes when the service finished
+	pid           int                  // the process id of the spawned or adopted dependency
+	args          []string             // the arguments the dependency was spawned with
+	parent        *clientConfig.Client // the parent passed to the dependency
+	startTime     time.Time
+	stopping      bool          // the dependency was requested to stop, so it's not restarted
+	siblingExit   chan error    // set while the group stops the dependency to restart it with the exited sibling
+	respawn       chan struct{} // set after the process exited until it's started again, closed to cancel the restart
+	done          chan error    // signalizes when the service finished
+	exited        chan struct{} // closed when the service finished, after the exitStatus is set
+	exitStatus    *ExitStatus
+	log           io.Closer   // the log file of the spawned dependency, closed when it exits
+	stderr        *tailBuffer // the last stderr lines of the spawned dependency for the CrashReport
 }
 
-// A DepManager Manager builds, runs or stops the dependency services
+// A DepManager Manager builds, runs or stops the dependency services.
+// It's safe for the concurrent use.
 type DepManager struct {
-	runningDeps map[string]*Dep
-	timeout     time.Duration
-
-	Src string `json:"SERVICE_DEPS_SRC"` // Default Src path
-	Bin string `json:"SERVICE_DEPS_BIN"`
+	mu           sync.RWMutex // guards the maps and the running state of the dependencies
+	runningDeps  map[string]*Dep
+	policies     map[string]*RestartPolicy // restart policies by the dependency id
+	supervisors  map[string]*supervisor    // restart history by the dependency id
+	events       map[string]chan *Event    // notifications by the dependency id
+	groups       map[string]*Group         // groups of the dependencies by the group id
+	pathLocks    map[string]*sync.Mutex    // the source code and binary locks by the path
+	calls        map[string]*call          // the in-flight installations by the paths
+	crashes      map[string][]*CrashReport // the last exits by the dependency id
+	health       map[string]*HealthStatus  // the heartbeat results by the dependency id
+	buildModes   map[string]BuildMode      // the build modes by the dependency url
+	monitor      *healthMonitor            // the running health monitor, optional
+	graph        *Graph                    // the start order of the dependencies, optional
+	lockMu       sync.Mutex                // guards the lockfile
+	timeout      time.Duration
+	cloneTimeout time.Duration // the limit of the source code download, no limit if it's 0
+	buildTimeout time.Duration // the limit of the module update and build, no limit if it's 0
+	closeGrace   time.Duration // the time to wait for the dependency to exit after the close command
+	termGrace    time.Duration // the time to wait for the dependency to exit after the termination signal
+	logMaxSize   int64         // the size of the log file to rotate
+	logMaxFiles  int           // the amount of the rotated log files
+	keepBins     int           // the amount of the built binaries kept for each dependency
+
+	Src    string `json:"SERVICE_DEPS_SRC"` // Default Src path
+	Bin    string `json:"SERVICE_DEPS_BIN"`
+	State  string `json:"SERVICE_DEPS_STATE"` // The records of the spawned dependencies
+	LogDir string `json:"SERVICE_DEPS_LOGS"`  // The output of the spawned dependencies
+	// The versions of the installed dependencies, optional
+	LockFile string `json:"SERVICE_DEPS_LOCK"`
 }
 
 // NewDep returns a dependency parameters. Pass empty strings if the dependency is managed by the DepManager.
@@ -81,7 +127,21 @@ func New() *DepManager {
 		Src:         "",
 		Bin:         "",
 		runningDeps: make(map[string]*Dep, 0),
+		policies:    make(map[string]*RestartPolicy, 0),
+		supervisors: make(map[string]*supervisor, 0),
+		events:      make(map[string]chan *Event, 0),
+		groups:      make(map[string]*Group, 0),
+		pathLocks:   make(map[string]*sync.Mutex, 0),
+		calls:       make(map[string]*call, 0),
+		crashes:     make(map[string][]*CrashReport, 0),
+		health:      make(map[string]*HealthStatus, 0),
+		buildModes:  make(map[string]BuildMode, 0),
 		timeout:     DefaultTimeout,
+		closeGrace:  DefaultCloseGrace,
+		termGrace:   DefaultTermGrace,
+		logMaxSize:  DefaultLogMaxSize,
+		logMaxFiles: DefaultLogMaxFiles,
+		keepBins:    DefaultKeepBins,
 	}
 }
 
@@ -101,12 +161,43 @@ func (dep *Dep) copy() *Dep {
 		binPath:       dep.binPath,
 		manageableBin: dep.manageableBin,
 		manageableSrc: dep.manageableSrc,
+		detached:      dep.detached,
+		readyTimeout:  dep.readyTimeout,
+		manager:       dep.manager,
 		done:          make(chan error, 1),
+		exited:        make(chan struct{}),
 	}
 
 	return instance
 }
 
+// SetDetached makes DepManager.Run to start the dependency in its own session or process group.
+// The detached dependency keeps running after the DepManager's process exits.
+// Its state is recorded in the DepManager.State directory, so the next DepManager re-attaches to it.
+func (dep *Dep) SetDetached(detached bool) {
+	if dep == nil {
+		return
+	}
+	dep.detached = detached
+}
+
+// Detached returns true if the dependency runs in its own session.
+func (dep *Dep) Detached() bool {
+	if dep == nil {
+		return false
+	}
+	return dep.detached
+}
+
+// SetManager sets the socket parameters of the dependency.
+// It's recorded in the state, so the re-attached dependency could be checked or closed by its socket.
+func (dep *Dep) SetManager(c *clientConfig.Client) {
+	if dep == nil {
+		return
+	}
+	dep.manager = c
+}
+
 // Lint sets the fields of Dep as for caching.
 // The two primary flags are whether the Dep is managed by DepManager or not.
 //
@@ -157,8 +248,62 @@ func (manager *DepManager) SetPaths(srcPath string, binPath string) error {
 	return nil
 }
 
-// Close the dependency
+// SetInstallTimeouts sets the limits of the source code download and the build.
+// The build timeout includes the module update.
+// Pass 0 to remove the limit.
+func (manager *DepManager) SetInstallTimeouts(cloneTimeout time.Duration, buildTimeout time.Duration) {
+	manager.mu.Lock()
+	manager.cloneTimeout = cloneTimeout
+	manager.buildTimeout = buildTimeout
+	manager.mu.Unlock()
+}
+
+// installTimeouts returns the limits of the source code download and the build
+func (manager *DepManager) installTimeouts() (time.Duration, time.Duration) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.cloneTimeout, manager.buildTimeout
+}
+
+// SetStatePath sets the directory where the records of the spawned dependencies are stored.
+// The directory is created if it doesn't exist.
+func (manager *DepManager) SetStatePath(statePath string) error {
+	if err := path.MakeDir(statePath); err != nil {
+		return fmt.Errorf("path.MakeDir(%s): %w", statePath, err)
+	}
+
+	manager.mu.Lock()
+	manager.State = statePath
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// statePath returns the directory of the records of the spawned dependencies
+func (manager *DepManager) statePath() string {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.State
+}
+
+// Close the dependency.
+//
+// If the dependency was spawned or adopted by this DepManager, then it's stopped by DepManager.Stop.
+// Therefore, the dependency that ignores the close command is killed.
 func (manager *DepManager) Close(c *clientConfig.Client) error {
+	manager.mu.RLock()
+	dep, spawned := manager.runningDeps[c.Id]
+	spawned = spawned && dep.pid > 0
+	manager.mu.RUnlock()
+	if spawned {
+		if _, err := manager.stop(c.Id, c); err != nil {
+			return fmt.Errorf("manager.stop('%s'): %w", c.Id, err)
+		}
+		return nil
+	}
+
 	// Make sure it's running
 	running, err := manager.Running(c)
 	if err != nil {
@@ -191,7 +336,7 @@ func (manager *DepManager) Close(c *clientConfig.Client) error {
 	}
 
 	if running {
-		return fmt.Errorf("manager is running even after closing")
+		return fmt.Errorf("%w: manager is running even after closing", ErrTimeout)
 	}
 
 	err = sock.Close()
@@ -223,23 +368,77 @@ func (manager *DepManager) Installed(dep *Dep) bool {
 // The Dep binary must be manageable.
 // If the Dep source code is manageable, then missing source code is downloaded as well.
 //
+// The concurrent installations of the same dependency are deduplicated into a single build.
+// The dependencies with the different source code and binary are built in parallel.
+//
 // Returns an error in two cases:
-//   - If the dependency binary is not manageable by the DepManager.
-//   - If no source code was given, and source code is not manageable by the DepManager.
+//   - ErrNotManageable if the dependency binary is not manageable by the DepManager.
+//   - ErrSourceMissing if no source code was given, and source code is not manageable by the DepManager.
+//
+// The failed download and build return ErrCloneFailed and ErrBuildFailed.
+// If the Dep has the Tag or Commit, then the source code is verified before the build.
+// The source code at another version returns ErrRefMismatch.
+//
+// The existing source code downloaded by the DepManager is switched to the Tag, Commit or Branch of the Dep.
+// If it has the uncommitted changes, then ErrSourceDirty is returned.
+//
+// The source code is built in its BuildMode, see DepManager.SetBuildMode.
+// If the modules are out of date, then ErrModulesOutdated is returned instead of ErrBuildFailed.
 func (manager *DepManager) Install(dep *Dep, parent *log.Logger) error {
-	if manager == nil || dep == nil || parent == nil {
+	return manager.InstallContext(context.Background(), dep, parent)
+}
+
+// InstallContext is the Install that stops the download and build when the context is done.
+// The partially downloaded source code is deleted.
+// The installation shared by the concurrent callers is stopped when the contexts of all of them are done.
+//
+// The download and build are limited by the timeouts set with DepManager.SetInstallTimeouts.
+// The exceeded deadline returns ErrTimeout, the cancellation returns context.Canceled.
+func (manager *DepManager) InstallContext(ctx context.Context, dep *Dep, parent *log.Logger) error {
+	if manager == nil || ctx == nil || dep == nil || parent == nil {
 		return fmt.Errorf("nil")
 	}
 
 	if !dep.IsLinted() {
-		return fmt.Errorf("depManager is not linted. Call DepManager.Lint(Dep) first")
+		return fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
 	}
 
 	if !dep.manageableBin {
-		return fmt.Errorf("can not install as the binary is not manageable by the DepManager")
+		return fmt.Errorf("can not install: %w", ErrNotManageable)
 	}
 
+	return manager.deduplicate(ctx, manager.installKey(dep), dep.progress, func(ctx context.Context, progress Progress) error {
+		unlock := manager.lockPaths(dep.srcPath, dep.binPath)
+		defer unlock()
+
+		// the user's dependency is not changed
+		shared := *dep
+		shared.progress = progress
+		return manager.install(ctx, &shared, parent)
+	})
+}
+
+// installKey returns the key of the installation shared by the concurrent callers.
+// The installations of the same paths at another version or build mode are not shared,
+// they wait for each other by the path locks instead.
+func (manager *DepManager) installKey(dep *Dep) string {
+	parts := []string{dep.srcPath, dep.binPath, dep.Branch, dep.Tag, dep.Commit}
+	parts = append(parts, manager.buildFlags(dep)...)
+
+	return strings.Join(parts, string(os.PathListSeparator))
+}
+
+// The install downloads the missing source code and builds the binary.
+// The dependency locked in the DepManager.LockFile is installed at the locked commit,
+// and the installed version is recorded in the lockfile.
+// The caller must lock the source code and binary paths.
+func (manager *DepManager) install(ctx context.Context, dep *Dep, parent *log.Logger) error {
 	logger := parent.Child("install", "srcUrl", dep.Url)
+	dep, err := manager.lockedDep(dep)
+	if err != nil {
+		return fmt.Errorf("manager.lockedDep: %w", err)
+	}
+	cloneTimeout, buildTimeout := manager.installTimeouts()
 	// check for a source exist
 	srcExist, err := manager.srcExist(dep)
 	if err != nil {
@@ -248,22 +447,54 @@ func (manager *DepManager) Install(dep *Dep, parent *log.Logger) error {
 
 	if !srcExist {
 		if !dep.manageableSrc {
-			return fmt.Errorf("no source code at '%s' path. and it's not manageable by DepManager", dep.srcPath)
+			return fmt.Errorf("%w at '%s' path. and it's not manageable by DepManager", ErrSourceMissing, dep.srcPath)
 		}
-		err = manager.downloadSrc(dep, logger)
+		if err := dep.stage(StageCloning); err != nil {
+			return fmt.Errorf("dep.stage('%s'): %w", StageCloning, err)
+		}
+		cloneCtx, cancel := withTimeout(ctx, cloneTimeout)
+		err = manager.downloadSrc(cloneCtx, dep, logger)
+		cancel()
 		if err != nil {
 			return fmt.Errorf("downloadSrc: %w", err)
 		}
+	} else if dep.manageableSrc {
+		// the existing checkout could be at another version
+		syncCtx, cancel := withTimeout(ctx, cloneTimeout)
+		err = manager.syncSrc(syncCtx, dep, logger)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("syncSrc: %w", err)
+		}
+	}
+
+	// the local source code could be at another version
+	if err := verifyRef(dep); err != nil {
+		return fmt.Errorf("verifyRef: %w", err)
 	}
 
-	err = manager.build(dep, logger)
+	buildCtx, cancel := withTimeout(ctx, buildTimeout)
+	defer cancel()
+	err = manager.build(buildCtx, dep, logger)
 	if err != nil {
 		return fmt.Errorf("build: %w", err)
 	}
 
+	if err := manager.lock(dep, manager.buildFlags(dep)); err != nil {
+		return fmt.Errorf("manager.lock: %w", err)
+	}
+
 	return nil
 }
 
+// withTimeout returns the context with the timeout. If the timeout is 0, then the context is not limited.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // The srcExist checks is the source code exist or not.
 // Since it is a private method, it assumes that depManager was linted.
 func (manager *DepManager) srcExist(dep *Dep) (bool, error) {
@@ -292,48 +523,102 @@ func (manager *DepManager) Running(c *clientConfig.Client) (bool, error) {
 	}
 
 	_, err = sock.Request(req)
+	closeErr := sock.Close()
 	if err != nil {
 		return false, nil
 	}
-
-	closeErr := sock.Close()
 	if closeErr != nil {
-		return false, fmt.Errorf("socket.Close: %w", err)
+		return false, fmt.Errorf("socket.Close: %w", closeErr)
 	}
 
 	return true, nil
 }
 
+// heartbeat sends the heartbeat to the dependency.
+// Returns the round trip of the reply.
+func (manager *DepManager) heartbeat(c *clientConfig.Client) (time.Duration, error) {
+	result, err := manager.Probe(c)
+	if err != nil {
+		return 0, fmt.Errorf("manager.Probe: %w", err)
+	}
+	if !result.Reachable {
+		return 0, fmt.Errorf("%s: %s", result.Failure, result.Error)
+	}
+
+	return result.Latency, nil
+}
+
 // The build the application from source code.
 // If the Dep is not manageable by DepManager, it returns an error.
 //
+// The binary is built into the temporary file, so the failed build doesn't change the installed binary.
+// Then it's kept as the version of the source code commit, and replaces the installed binary. See DepManager.Rollback.
+//
 // Since it's a private method, it assumes the depManager is linted, and its binary is manageable by DepManager.
-func (manager *DepManager) build(dep *Dep, logger *log.Logger) error {
-	err := cleanBuild(dep.srcPath, logger)
-	if err != nil {
-		return fmt.Errorf("cleanBuild(%s): %w", dep.srcPath, err)
+func (manager *DepManager) build(ctx context.Context, dep *Dep, logger *log.Logger) error {
+	mode := manager.BuildMode(dep)
+	if mode == BuildTidy {
+		// the developer's go.mod and go.sum are not changed behind their back
+		if !dep.manageableSrc {
+			return fmt.Errorf("can not tidy the local source code '%s': %w", dep.srcPath, ErrNotManageable)
+		}
+		if err := dep.stage(StageTidying); err != nil {
+			return fmt.Errorf("dep.stage('%s'): %w", StageTidying, err)
+		}
+		err := cleanBuild(ctx, dep.srcPath, logger, dep.progress)
+		if err != nil {
+			return fmt.Errorf("cleanBuild(%s): %w", dep.srcPath, err)
+		}
 	}
 
-	cmd := exec.Command("go", "build", "-o", dep.binPath)
-	cmd.Stdout = logger.Child("build", "binUrl", dep.binPath)
+	if err := dep.stage(StageBuilding); err != nil {
+		return fmt.Errorf("dep.stage('%s'): %w", StageBuilding, err)
+	}
+	builtPath := dep.binPath + buildSuffix
+	args := append([]string{"build"}, manager.buildFlags(dep)...)
+	cmd := exec.CommandContext(ctx, "go", append(args, "-o", builtPath)...)
+	cmd.Stdout = withProgress(logger.Child("build", "binUrl", dep.binPath), dep.progress)
 	cmd.Dir = dep.srcPath
-	cmd.Stderr = logger.Child("buildErr", "binUrl", dep.binPath)
-	err = cmd.Run()
+	// the last lines tell whether the modules are out of date
+	stderr := newTailBuffer(buildOutputLines)
+	cmd.Stderr = io.MultiWriter(withProgress(logger.Child("buildErr", "binUrl", dep.binPath), dep.progress), stderr)
+	err := cmd.Run()
 	if err != nil {
-		return fmt.Errorf("cmd.Start: %w", err)
+		_ = os.Remove(builtPath)
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return fmt.Errorf("cmd.Run: %w", ctxErr)
+		}
+		if modulesErr := modulesError(dep.srcPath, mode, stderr.lines()); modulesErr != nil {
+			return modulesErr
+		}
+		return newError(ErrBuildFailed, fmt.Errorf("cmd.Run: %w", err))
 	}
+
+	commit, err := srcCommit(dep.srcPath)
+	if err != nil {
+		_ = os.Remove(builtPath)
+		return fmt.Errorf("srcCommit('%s'): %w", dep.srcPath, err)
+	}
+	if err := manager.installBin(dep, builtPath, commit); err != nil {
+		_ = os.Remove(builtPath)
+		return fmt.Errorf("manager.installBin: %w", err)
+	}
+
 	return nil
 }
 
 // OnStop returns a signal through the channel when the dependency spawned by the DepManager stops.
 // If the dep is not existing, then it will simply return error.
 func (manager *DepManager) OnStop(id string) chan error {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
 	dep, ok := manager.runningDeps[id]
 	if !ok {
 		return nil
 	}
 
-	if dep.cmd == nil {
+	if dep.cmd == nil && dep.pid == 0 {
 		return nil
 	}
 
@@ -345,8 +630,17 @@ func (manager *DepManager) OnStop(id string) chan error {
 //
 // Note that, services can crash during the initialization.
 // In that case, you should use DepManager.OnStop method.
+// Or set Dep.SetReadyTimeout, then Run waits until the dependency replies to the heartbeat. See DepManager.WaitReady.
+// The dependency that is not ready is stopped.
 //
 // If a parent is given, it's passed as ParentFlag.
+//
+// If the DepManager.State is set, then the pid and arguments of the dependency are recorded there.
+// See DepManager.Reconcile.
+//
+// If the dep is detached, then the dependency runs in its own session with the output written
+// into the '<id>.log' file in the DepManager.State directory.
+//
 // Todo, move all Flags from service-lib to config-lig.
 // Todo, use the ParentFlag from the config lig
 func (manager *DepManager) Run(dep *Dep, id string, optionalParent ...*clientConfig.Client) error {
@@ -358,17 +652,12 @@ func (manager *DepManager) Run(dep *Dep, id string, optionalParent ...*clientCon
 	}
 
 	if !dep.IsLinted() {
-		return fmt.Errorf("depManager is not linted. Call DepManager.Lint(Dep) first")
-	}
-
-	_, ok := manager.runningDeps[id]
-	if ok {
-		return fmt.Errorf("the dep with id '%s' already running", id)
+		return fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
 	}
 
-	ok = manager.Installed(dep)
+	ok := manager.Installed(dep)
 	if !ok {
-		return fmt.Errorf("no binary. Call DepManager.Install(Dep, log.Logger) first")
+		return fmt.Errorf("%w: no binary. Call DepManager.Install(Dep, log.Logger) first", ErrNotInstalled)
 	}
 
 	configFlag := fmt.Sprintf("--url=%s", dep.Url)
@@ -378,52 +667,257 @@ func (manager *DepManager) Run(dep *Dep, id string, optionalParent ...*clientCon
 	args[0] = configFlag
 	args[1] = idFlag
 
+	var parent *clientConfig.Client
 	if len(optionalParent) == 1 {
-		parentKv, err := key_value.NewFromInterface(optionalParent[0])
+		parent = optionalParent[0]
+		parentKv, err := key_value.NewFromInterface(parent)
 		if err != nil {
-			return fmt.Errorf("optionalParent: key_value.NewFromInterface(parent='%v'): %w", optionalParent[0], err)
+			return fmt.Errorf("optionalParent: key_value.NewFromInterface(parent='%v'): %w", parent, err)
 		}
 		parentFlag := fmt.Sprintf("--parent=%s", parentKv.String())
 		args = append(args, parentFlag)
 	}
 
-	instance := dep.copy()
+	if dep.readyTimeout > 0 && dep.manager == nil {
+		return fmt.Errorf("no socket parameters to wait for the heartbeat. Call Dep.SetManager first")
+	}
 
+	if dep.detached && len(manager.statePath()) == 0 {
+		return fmt.Errorf("no state path to record the detached dep. Call DepManager.SetStatePath first")
+	}
+
+	instance := dep.copy()
+	instance.args = args
+	instance.parent = parent
+
+	// the id is reserved, so the concurrent runs with the same id fail
+	manager.mu.Lock()
+	if _, ok := manager.runningDeps[id]; ok {
+		manager.mu.Unlock()
+		return fmt.Errorf("the '%s' %w", id, ErrAlreadyRunning)
+	}
 	manager.runningDeps[id] = instance
+	// the new run is supervised from scratch
+	delete(manager.supervisors, id)
+	manager.mu.Unlock()
 
-	logger, err := log.New(id, false)
-	if err != nil {
-		return fmt.Errorf("log.New('%s'): %w", id, err)
+	if err := manager.start(id, instance); err != nil {
+		manager.mu.Lock()
+		delete(manager.runningDeps, id)
+		manager.mu.Unlock()
+		return fmt.Errorf("manager.start('%s'): %w", id, err)
 	}
-	errLogger, err := log.New(id+"Err", false)
-	if err != nil {
-		return fmt.Errorf("log.New('%sErr'): %w", id, err)
+
+	manager.wait(id)
+
+	if instance.readyTimeout > 0 {
+		// the instance is used, since the dependency that crashed at once is removed from the running ones
+		if err := manager.ready(id, instance.manager, instance.exited, instance.readyTimeout); err != nil {
+			// the caller doesn't stop the dependency, as the run failed
+			manager.mu.RLock()
+			spawned := manager.runningDeps[id] == instance
+			manager.mu.RUnlock()
+			if spawned {
+				if _, stopErr := manager.Stop(id); stopErr != nil {
+					return fmt.Errorf("manager.ready: %w; manager.Stop: %v", err, stopErr)
+				}
+			}
+			return fmt.Errorf("manager.ready: %w", err)
+		}
 	}
 
-	cmd := exec.Command(dep.binPath, args...)
-	cmd.Stdout = logger
-	cmd.Stderr = errLogger
-	err = cmd.Start()
+	return nil
+}
+
+// The start spawns the process of the dependency instance, and records it in the DepManager.State.
+// It's called by DepManager.Run and by the supervisor to restart the dependency.
+//
+// If the DepManager.LogDir is set, then the output is written to the log file of the dependency.
+// The detached dependency writes to the file directly, so its log is rotated only before the start.
+func (manager *DepManager) start(id string, instance *Dep) error {
+	manager.mu.RLock()
+	maxSize, maxFiles := manager.logMaxSize, manager.logMaxFiles
+	statePath, logDir := manager.State, manager.LogDir
+	manager.mu.RUnlock()
+
+	var depLog io.Closer
+	var stderr *tailBuffer
+	cmd := exec.Command(instance.binPath, instance.args...)
+	if instance.detached {
+		logPath := filepath.Join(statePath, urlToFileName(id)+".log")
+		if len(logDir) > 0 {
+			logPath = manager.logPath(id)
+			if stat, err := os.Stat(logPath); err == nil && stat.Size() >= maxSize {
+				if err := rotateLog(logPath, maxFiles); err != nil {
+					return fmt.Errorf("rotateLog('%s'): %w", logPath, err)
+				}
+			}
+		}
+		logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("os.OpenFile('%s'): %w", logPath, err)
+		}
+		// the child process has its own copy of the file descriptor
+		defer func() {
+			_ = logFile.Close()
+		}()
+
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		cmd.SysProcAttr = detachAttr()
+	} else if len(logDir) > 0 {
+		logFile, err := openLog(manager.logPath(id), maxSize, maxFiles)
+		if err != nil {
+			return fmt.Errorf("openLog('%s'): %w", id, err)
+		}
+		depLog = logFile
+
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		cmd.SysProcAttr = groupAttr()
+	} else {
+		logger, err := log.New(id, false)
+		if err != nil {
+			return fmt.Errorf("log.New('%s'): %w", id, err)
+		}
+		errLogger, err := log.New(id+"Err", false)
+		if err != nil {
+			return fmt.Errorf("log.New('%sErr'): %w", id, err)
+		}
+
+		cmd.Stdout = logger
+		cmd.Stderr = errLogger
+		// the process group is killed by DepManager.Stop along with the children of the dependency
+		cmd.SysProcAttr = groupAttr()
+	}
+	// the detached dependency writes to the file directly
+	if !instance.detached {
+		stderr = newTailBuffer(CrashOutputLines)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	}
+
+	err := cmd.Start()
 	if err != nil {
+		if depLog != nil {
+			_ = depLog.Close()
+		}
 		return fmt.Errorf("cmd.Start: %w", err)
 	}
 
+	startTime := time.Now()
+	manager.mu.Lock()
+	instance.log = depLog
+	instance.stderr = stderr
 	instance.cmd = cmd
-	manager.wait(id)
+	instance.pid = cmd.Process.Pid
+	instance.respawn = nil
+	instance.startTime = startTime
+	manager.mu.Unlock()
+
+	if len(statePath) > 0 {
+		record := &Record{
+			Id:        id,
+			Url:       instance.Url,
+			Pid:       cmd.Process.Pid,
+			BinPath:   instance.binPath,
+			Args:      instance.args,
+			Parent:    instance.parent,
+			Manager:   instance.manager,
+			Detached:  instance.detached,
+			StartTime: startTime,
+		}
+		if err := saveRecord(statePath, record); err != nil {
+			// untracked dependency would be left forever if this process crashes
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			if depLog != nil {
+				_ = depLog.Close()
+			}
+			return fmt.Errorf("saveRecord('%s'): %w", id, err)
+		}
+	}
 
 	return nil
 }
 
 // The wait is invoked if the spawned dependency stops.
 // The dependencies are running asynchronously.
+// If the dependency has a RestartPolicy, then the exited dependency may be restarted instead.
 // In order to call this function, you must use the DepManager.Close() method.
 // If the Close signal was sent to the spawned child, then
 // this method will be called automatically by the operating system.
 func (manager *DepManager) wait(id string) {
+	manager.mu.RLock()
+	instance := manager.runningDeps[id]
+	cmd := instance.cmd
+	depLog := instance.log
+	stderr := instance.stderr
+	startTime := instance.startTime
+	manager.mu.RUnlock()
+
 	go func() {
-		err := manager.runningDeps[id].cmd.Wait() // it can return an error
-		manager.runningDeps[id].done <- err
+		err := cmd.Wait() // it can return an error
+		if depLog != nil {
+			_ = depLog.Close()
+		}
+		manager.mu.Lock()
+		// the pid is not signaled anymore, as it may be reused
+		instance.respawn = make(chan struct{})
+		// the group kills the sibling deliberately, it's not a crash.
+		// the exit is passed under the lock, so the group can't miss it.
+		if siblingExit := instance.siblingExit; siblingExit != nil {
+			siblingExit <- err
+			manager.mu.Unlock()
+			return
+		}
+		manager.mu.Unlock()
+
+		manager.exit(id, instance, startTime, stderr, err)
+	}()
+}
+
+// The exit records the crash of the exited dependency.
+// Then the dependency is restarted by its RestartPolicy or released.
+func (manager *DepManager) exit(id string, instance *Dep, startTime time.Time, stderr *tailBuffer, err error) {
+	manager.recordCrash(id, instance.binPath, instance.args, startTime, stderr, err)
+	if manager.supervise(id, instance, err) {
+		return
+	}
+	manager.release(id, instance, err)
+}
+
+// The release removes the exited dependency from the DepManager, and notifies about its exit.
+func (manager *DepManager) release(id string, instance *Dep, err error) {
+	if statePath := manager.statePath(); len(statePath) > 0 {
+		_ = deleteRecord(statePath, id)
+	}
+
+	manager.mu.Lock()
+	if manager.runningDeps[id] == instance {
 		delete(manager.runningDeps, id)
+	}
+	instance.exitStatus = newExitStatus(err)
+	close(instance.exited)
+	manager.mu.Unlock()
+
+	instance.done <- err
+}
+
+// The watch polls the adopted dependency until its process exits.
+// Since the adopted dependency is not the child of this process, it can't be waited.
+func (manager *DepManager) watch(id string) {
+	manager.mu.RLock()
+	instance := manager.runningDeps[id]
+	pid := instance.pid
+	startTime := instance.startTime
+	manager.mu.RUnlock()
+
+	go func() {
+		for processAlive(pid) {
+			time.Sleep(watchInterval)
+		}
+		manager.recordCrash(id, instance.binPath, instance.args, startTime, nil, nil)
+		manager.release(id, instance, nil)
 	}()
 }
 
@@ -434,24 +928,38 @@ func (manager *DepManager) wait(id string) {
 // The Dep may have a local src code.
 // This method doesn't check for that.
 // Therefore, if the Dep has a LocalUrl(), then don't call this method.
-func (manager *DepManager) downloadSrc(dep *Dep, logger *log.Logger) error {
+//
+// The Tag or Branch is cloned, then the Commit is checked out.
+// If the download fails or the context is done, then the partially downloaded source code is deleted.
+func (manager *DepManager) downloadSrc(ctx context.Context, dep *Dep, logger *log.Logger) error {
 	if !dep.manageableSrc {
 		return fmt.Errorf("source is not manageable by the DepManager")
 	}
 
 	options := &git.CloneOptions{
 		URL:      dep.GitUrl,
-		Progress: logger.Child("download"),
+		Progress: withProgress(logger.Child("download"), dep.progress),
 	}
 
-	if len(dep.Branch) > 0 {
+	if len(dep.Tag) > 0 {
+		options.ReferenceName = plumbing.NewTagReferenceName(dep.Tag)
+	} else if len(dep.Branch) > 0 {
 		options.ReferenceName = plumbing.NewBranchReferenceName(dep.Branch)
 	}
 
-	_, err := git.PlainClone(dep.srcPath, false, options)
+	repo, err := git.PlainCloneContext(ctx, dep.srcPath, false, options)
+	if err == nil {
+		err = checkoutRef(repo, dep)
+	}
 
 	if err != nil {
-		return fmt.Errorf("git.PlainClone --url %s --o %s: %w", dep.Url, dep.srcPath, err)
+		if removeErr := os.RemoveAll(dep.srcPath); removeErr != nil {
+			logger.Warn("failed to delete the partial source code", "srcPath", dep.srcPath, "error", removeErr)
+		}
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return fmt.Errorf("git.PlainCloneContext --url %s --o %s: %w", dep.Url, dep.srcPath, ctxErr)
+		}
+		return newError(ErrCloneFailed, fmt.Errorf("git.PlainCloneContext --url %s --o %s: %w", dep.Url, dep.srcPath, err))
 	}
 
 	return nil
@@ -468,23 +976,26 @@ func (manager *DepManager) deleteSrc(dep *Dep) error {
 	return nil
 }
 
-// deleteBin deletes the binary from the directory.
+// deleteBin deletes the binary from the directory along with its kept versions.
 // If there is no binary, it will throw an error.
 // If attempt to delete failed, it will throw an error.
 //
 // This method is private, so it assumes Dep is linted by the caller.
 func (manager *DepManager) deleteBin(dep *Dep) error {
 	if !dep.manageableBin {
-		return fmt.Errorf("depManager binary is not manageable by the DepManager")
+		return fmt.Errorf("depManager %w", ErrNotManageable)
 	}
 
 	if !manager.Installed(dep) {
-		return fmt.Errorf("depManager '%s' not installed", dep.Url)
+		return fmt.Errorf("depManager '%s': %w", dep.Url, ErrNotInstalled)
 	}
 
 	if err := os.Remove(dep.binPath); err != nil {
 		return fmt.Errorf("os.Remove('%s'): %w", dep.binPath, err)
 	}
+	if err := deleteBinVersions(dep.binPath); err != nil {
+		return fmt.Errorf("deleteBinVersions('%s'): %w", dep.binPath, err)
+	}
 
 	return nil
 }
@@ -500,13 +1011,16 @@ func (manager *DepManager) Uninstall(dep *Dep) error {
 	}
 
 	if !dep.IsLinted() {
-		return fmt.Errorf("depManager is not linted. Call DepManager.Lint(Dep) first")
+		return fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
 	}
 
 	if !dep.manageableBin && !dep.manageableSrc {
 		return nil
 	}
 
+	unlock := manager.lockPaths(dep.srcPath, dep.binPath)
+	defer unlock()
+
 	if dep.manageableSrc {
 		exist, err := manager.srcExist(dep)
 		if err != nil {
@@ -534,15 +1048,18 @@ func (manager *DepManager) Uninstall(dep *Dep) error {
 	return nil
 }
 
-// calls `go mod tidy`
-func cleanBuild(srcUrl string, logger *log.Logger) error {
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Stdout = logger.Child("clean")
+// calls `go mod tidy`. The output is written to the progress as well, if it's given.
+func cleanBuild(ctx context.Context, srcUrl string, logger *log.Logger, progress io.Writer) error {
+	cmd := exec.CommandContext(ctx, "go", "mod", "tidy")
+	cmd.Stdout = withProgress(logger.Child("clean"), progress)
 	cmd.Dir = srcUrl
-	cmd.Stderr = logger.Child("cleanErr")
+	cmd.Stderr = withProgress(logger.Child("cleanErr"), progress)
 	err := cmd.Run()
 	if err != nil {
-		return fmt.Errorf("cmd.Start: %w", err)
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return fmt.Errorf("cmd.Run: %w", ctxErr)
+		}
+		return newError(ErrBuildFailed, fmt.Errorf("cmd.Run: %w", err))
 	}
 
 	return nil
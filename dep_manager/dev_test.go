@@ -1,6 +1,7 @@
 package dep_manager
 
 import (
+	"context"
 	"fmt"
 	clientConfig "github.com/ahmetson/client-lib/config"
 	"github.com/ahmetson/log-lib"
@@ -47,12 +48,9 @@ func (test *TestDepManagerSuite) SetupTest() {
 	binPath := path.AbsDir(currentDir, "_sds/bin")
 
 	// Make sure that the folders don't exist. They will be added later
-	test.depManager = &DepManager{
-		Src:         srcPath,
-		Bin:         binPath,
-		runningDeps: make(map[string]*Dep, 0),
-		timeout:     DefaultTimeout,
-	}
+	test.depManager = New()
+	test.depManager.Src = srcPath
+	test.depManager.Bin = binPath
 
 	// A valid source code that we want to download
 	test.url = "github.com/ahmetson/test-manager"
@@ -217,7 +215,7 @@ func (test *TestDepManagerSuite) Test_13_downloadSrc() {
 	s().False(exist)
 
 	// download the source code
-	err = test.depManager.downloadSrc(dep, test.logger)
+	err = test.depManager.downloadSrc(context.Background(), dep, test.logger)
 	s().NoError(err)
 
 	// There should be a source code
@@ -237,7 +235,7 @@ func (test *TestDepManagerSuite) Test_13_downloadSrc() {
 	url := "github.com/ahmetson/no-repo" // this repo doesn't exist
 	dep, err = NewDep(url, "", "")
 	s().NoError(err)
-	err = test.depManager.downloadSrc(dep, test.logger)
+	err = test.depManager.downloadSrc(context.Background(), dep, test.logger)
 	s().Error(err)
 }
 
@@ -259,7 +257,7 @@ func (test *TestDepManagerSuite) Test_14_build() {
 	s().False(exist)
 
 	// build the binaries
-	err = test.depManager.build(dep, test.logger)
+	err = test.depManager.build(context.Background(), dep, test.logger)
 	s().NoError(err)
 
 	// There should be a binary after testing
@@ -551,7 +549,7 @@ func (test *TestDepManagerSuite) Test_19_InvalidCompile() {
 	s().False(exist)
 
 	// building must fail, since "uncompilable" branch code is not buildable
-	err = test.depManager.build(uncompilableDep, test.logger)
+	err = test.depManager.build(context.Background(), uncompilableDep, test.logger)
 	s().Error(err)
 }
 
@@ -0,0 +1,121 @@
+package dep_manager
+
+import (
+	"context"
+	"errors"
+)
+
+// The errors of the DepManager. Check them with errors.Is.
+// The dep_client returns the same errors, as the error code is passed along with the reply.
+var (
+	ErrNotLinted       = errors.New("dep is not linted")
+	ErrNotManageable   = errors.New("binary is not manageable by the DepManager")
+	ErrAlreadyRunning  = errors.New("dep is already running")
+	ErrNotInstalled    = errors.New("dep is not installed")
+	ErrSourceMissing   = errors.New("no source code")
+	ErrBuildFailed     = errors.New("build failed")
+	ErrCloneFailed     = errors.New("clone failed")
+	ErrTimeout         = errors.New("timeout")
+	ErrExited          = errors.New("dep exited")
+	ErrRefMismatch     = errors.New("source code is not at the pinned tag or commit")
+	ErrSourceDirty     = errors.New("source code has uncommitted changes")
+	ErrNoPrevious      = errors.New("no previous binary")
+	ErrModulesOutdated = errors.New("modules are out of date")
+	ErrNotFound        = errors.New("not found")
+	ErrCanceled        = errors.New("canceled")
+)
+
+// codes are the wire codes of the errors
+var codes = []struct {
+	kind error
+	code string
+}{
+	{ErrNotLinted, "not-linted"},
+	{ErrNotManageable, "not-manageable"},
+	{ErrAlreadyRunning, "already-running"},
+	{ErrNotInstalled, "not-installed"},
+	{ErrSourceMissing, "source-missing"},
+	{ErrBuildFailed, "build-failed"},
+	{ErrCloneFailed, "clone-failed"},
+	{ErrTimeout, "timeout"},
+	{ErrExited, "exited"},
+	{ErrRefMismatch, "ref-mismatch"},
+	{ErrSourceDirty, "source-dirty"},
+	{ErrNoPrevious, "no-previous"},
+	{ErrModulesOutdated, "modules-outdated"},
+	{ErrNotFound, "not-found"},
+	{ErrCanceled, "canceled"},
+}
+
+// An Error is the failure of the given kind caused by another error.
+// The Kind is one of the DepManager errors, and it matches with errors.Is.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// newError returns the error of the kind caused by err
+func newError(kind error, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the kind of the error
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// Code returns the wire code of the error kind
+func (e *Error) Code() string {
+	return ErrorCode(e.Kind)
+}
+
+// ErrorCode returns the wire code of the DepManager error in the chain.
+// Returns an empty string if the error is not the DepManager error.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range codes {
+		if errors.Is(err, c.kind) {
+			return c.code
+		}
+	}
+
+	return ""
+}
+
+// contextError returns the error of the done context.
+// The deadline is returned as ErrTimeout, the cancellation as context.Canceled.
+// Returns nil if the context is not done.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if err == context.DeadlineExceeded {
+		return newError(ErrTimeout, err)
+	}
+
+	return err
+}
+
+// FromCode returns the error with the message, that matches the DepManager error by its code.
+// If the code is not known, then the plain error with the message is returned.
+func FromCode(code string, message string) error {
+	for _, c := range codes {
+		if c.code == code {
+			return newError(c.kind, errors.New(message))
+		}
+	}
+
+	return errors.New(message)
+}
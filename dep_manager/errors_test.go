@@ -0,0 +1,102 @@
+package dep_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestErrorsSuite struct {
+	suite.Suite
+}
+
+// Test_10_ErrorCode tests the codes of the wrapped errors
+func (test *TestErrorsSuite) Test_10_ErrorCode() {
+	s := test.Require
+
+	s().Empty(ErrorCode(nil))
+	s().Empty(ErrorCode(fmt.Errorf("unknown")))
+
+	err := fmt.Errorf("h.manager.Run: %w", fmt.Errorf("the 'id' %w", ErrAlreadyRunning))
+	s().Equal("already-running", ErrorCode(err))
+
+	// the kind and the cause are both in the chain
+	cause := fmt.Errorf("exit status 1")
+	err = fmt.Errorf("build: %w", newError(ErrBuildFailed, cause))
+	s().Equal("build-failed", ErrorCode(err))
+	s().ErrorIs(err, ErrBuildFailed)
+	s().ErrorIs(err, cause)
+	s().NotErrorIs(err, ErrCloneFailed)
+
+	var typedErr *Error
+	s().True(errors.As(err, &typedErr))
+	s().Equal("build-failed", typedErr.Code())
+}
+
+// Test_11_FromCode tests that the errors are restored from their codes
+func (test *TestErrorsSuite) Test_11_FromCode() {
+	s := test.Require
+
+	kinds := []error{
+		ErrNotLinted,
+		ErrNotManageable,
+		ErrAlreadyRunning,
+		ErrNotInstalled,
+		ErrSourceMissing,
+		ErrBuildFailed,
+		ErrCloneFailed,
+		ErrTimeout,
+		ErrExited,
+		ErrRefMismatch,
+		ErrSourceDirty,
+		ErrNoPrevious,
+		ErrModulesOutdated,
+		ErrNotFound,
+		ErrCanceled,
+	}
+	for _, kind := range kinds {
+		code := ErrorCode(kind)
+		s().NotEmpty(code)
+
+		err := FromCode(code, "reply.Message: failed")
+		s().ErrorIs(err, kind)
+		s().Equal("reply.Message: failed", err.Error())
+	}
+
+	// unknown code
+	err := FromCode("no-code", "reply.Message: failed")
+	s().Empty(ErrorCode(err))
+	s().Equal("reply.Message: failed", err.Error())
+}
+
+// Test_12_ContextError tests the errors of the done contexts
+func (test *TestErrorsSuite) Test_12_ContextError() {
+	s := test.Require
+
+	s().NoError(contextError(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := contextError(ctx)
+	s().ErrorIs(err, context.Canceled)
+	s().Empty(ErrorCode(err))
+
+	ctx, cancel = context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	err = contextError(ctx)
+	s().ErrorIs(err, ErrTimeout)
+	s().ErrorIs(err, context.DeadlineExceeded)
+	s().Equal("timeout", ErrorCode(err))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(TestErrorsSuite))
+}
@@ -0,0 +1,59 @@
+package dep_manager
+
+import "time"
+
+// eventBuffer is the amount of the events kept for each dependency until they are read.
+// The events are dropped when the buffer is full.
+const eventBuffer = 16
+
+// EventType is the kind of the notification about the spawned dependency
+type EventType = string
+
+const (
+	EventRestarting EventType = "restarting" // the dependency exited, and it will be restarted after the backoff
+	EventRestarted  EventType = "restarted"  // the dependency was spawned again
+	EventFailed     EventType = "failed"     // the dependency will not be restarted anymore
+	EventReady      EventType = "ready"      // the dependency replied to the heartbeat after the start
+	EventNotReady   EventType = "not-ready"  // the dependency exited or didn't reply in time after the start
+	EventHealth     EventType = "health"     // the health of the dependency changed, see DepManager.StartHealthMonitor
+)
+
+// An Event is the notification about the spawned dependency
+type Event struct {
+	Id      string
+	Type    EventType
+	Time    time.Time
+	Attempt uint64        // the restart attempt within the RestartPolicy.Window
+	Delay   time.Duration // the backoff before the restart
+	Err     error         // the exit error of the dependency, or the reason of the failure
+	Health  Health        // the new health of the dependency
+}
+
+// OnEvent returns the channel with the notifications about the dependency.
+// The channel is shared by all callers, and it's kept after the dependency stops.
+func (manager *DepManager) OnEvent(id string) chan *Event {
+	if manager == nil {
+		return nil
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	events, ok := manager.events[id]
+	if !ok {
+		events = make(chan *Event, eventBuffer)
+		manager.events[id] = events
+	}
+
+	return events
+}
+
+// emit the event without blocking. If nobody reads the events, then the new events are dropped.
+func (manager *DepManager) emit(event *Event) {
+	event.Time = time.Now()
+
+	select {
+	case manager.OnEvent(event.Id) <- event:
+	default:
+	}
+}
@@ -0,0 +1,384 @@
+package dep_manager
+
+import (
+	"errors"
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Graph is the dependencies between the dependencies by their ids.
+// The dependency starts after all dependencies it requires, and stops before them.
+type Graph struct {
+	Requires map[string][]string `json:"requires"` // the required ids by the dependency id
+}
+
+// A ResolvedGraph is the start order of the Graph.
+type ResolvedGraph struct {
+	Requires map[string][]string `json:"requires"`
+	// Levels are the dependencies that start in parallel.
+	// The dependencies of the level require only the dependencies of the previous levels.
+	Levels [][]string `json:"levels"`
+	Order  []string   `json:"order"` // the start order, the stop order is reversed
+}
+
+// NewGraph returns an empty graph
+func NewGraph() *Graph {
+	return &Graph{Requires: make(map[string][]string)}
+}
+
+// Require adds the dependency that runs after the required dependencies.
+// The required dependencies are added to the graph as well.
+func (graph *Graph) Require(id string, requires ...string) *Graph {
+	graph.Requires[id] = append(graph.Requires[id], requires...)
+	for _, required := range requires {
+		if _, ok := graph.Requires[required]; !ok {
+			graph.Requires[required] = []string{}
+		}
+	}
+
+	return graph
+}
+
+// IsValid returns an error if the graph has empty ids, unknown dependencies or cycles
+func (graph *Graph) IsValid() error {
+	if _, err := graph.Resolve(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Resolve returns the start order of the dependencies.
+// The dependencies on the same level are sorted by their id.
+// Returns an error with the path of the cycle if the dependencies require each other.
+func (graph *Graph) Resolve() (*ResolvedGraph, error) {
+	if graph == nil {
+		return nil, fmt.Errorf("nil")
+	}
+
+	requires := make(map[string][]string, len(graph.Requires))
+	for id, required := range graph.Requires {
+		if len(id) == 0 {
+			return nil, fmt.Errorf("empty dependency id")
+		}
+		unique := make([]string, 0, len(required))
+		seen := make(map[string]bool, len(required))
+		for _, requiredId := range required {
+			if requiredId == id {
+				return nil, fmt.Errorf("the '%s' dependency requires itself", id)
+			}
+			if _, ok := graph.Requires[requiredId]; !ok {
+				return nil, fmt.Errorf("the '%s' dependency requires unknown '%s'", id, requiredId)
+			}
+			if !seen[requiredId] {
+				seen[requiredId] = true
+				unique = append(unique, requiredId)
+			}
+		}
+		sort.Strings(unique)
+		requires[id] = unique
+	}
+
+	if cycle := findCycle(requires); len(cycle) > 0 {
+		return nil, fmt.Errorf("cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	resolved := &ResolvedGraph{
+		Requires: requires,
+		Levels:   make([][]string, 0),
+		Order:    make([]string, 0, len(requires)),
+	}
+	started := make(map[string]bool, len(requires))
+	for len(started) < len(requires) {
+		level := make([]string, 0)
+		for id, required := range requires {
+			if started[id] {
+				continue
+			}
+			ready := true
+			for _, requiredId := range required {
+				if !started[requiredId] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, id)
+			}
+		}
+		sort.Strings(level)
+		for _, id := range level {
+			started[id] = true
+		}
+		resolved.Levels = append(resolved.Levels, level)
+		resolved.Order = append(resolved.Order, level...)
+	}
+
+	return resolved, nil
+}
+
+// findCycle returns the path of the first cycle in the graph, or nil if there is none.
+// The ids are visited in the sorted order, so the same cycle is returned each time.
+func findCycle(requires map[string][]string) []string {
+	ids := make([]string, 0, len(requires))
+	for id := range requires {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := make(map[string]int, len(requires))
+	path := make([]string, 0)
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		states[id] = visiting
+		path = append(path, id)
+		for _, requiredId := range requires[id] {
+			switch states[requiredId] {
+			case visiting:
+				for i, pathId := range path {
+					if pathId == requiredId {
+						cycle := append([]string{}, path[i:]...)
+						return append(cycle, requiredId)
+					}
+				}
+			case 0:
+				if cycle := visit(requiredId); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[id] = visited
+		return nil
+	}
+
+	for _, id := range ids {
+		if states[id] == 0 {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetGraph sets the dependencies between the dependencies used by RunGraph and StopGraph.
+// If the graph is set already, then it's replaced.
+func (manager *DepManager) SetGraph(graph *Graph) error {
+	if manager == nil {
+		return fmt.Errorf("nil")
+	}
+	if err := graph.IsValid(); err != nil {
+		return fmt.Errorf("graph.IsValid: %w", err)
+	}
+
+	requires := make(map[string][]string, len(graph.Requires))
+	for id, required := range graph.Requires {
+		requires[id] = append([]string{}, required...)
+	}
+
+	manager.mu.Lock()
+	manager.graph = &Graph{Requires: requires}
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// Graph returns the start order of the graph set by SetGraph.
+// Returns nil if there is no graph.
+func (manager *DepManager) Graph() *ResolvedGraph {
+	if manager == nil {
+		return nil
+	}
+
+	manager.mu.RLock()
+	graph := manager.graph
+	manager.mu.RUnlock()
+	if graph == nil {
+		return nil
+	}
+
+	// the graph was validated by SetGraph
+	resolved, _ := graph.Resolve()
+	return resolved
+}
+
+// RunGraph runs the dependencies of the graph set by SetGraph.
+// The deps are given by their id. The dependency that is not in the graph doesn't require others.
+//
+// The dependency starts after all dependencies it requires have started.
+// The dependencies of the independent branches start in parallel.
+// Set Dep.SetReadyTimeout to start the dependency after the required dependencies reply to the heartbeat.
+//
+// The required dependency must be in the deps or be running already.
+// If any dependency fails to start, then the started ones are stopped in the reverse order.
+// The dependency that failed after it was spawned, e.g. it's not ready, is stopped as well.
+func (manager *DepManager) RunGraph(deps map[string]*Dep, optionalParent ...*clientConfig.Client) error {
+	if manager == nil || len(deps) == 0 {
+		return fmt.Errorf("nil or no deps")
+	}
+
+	manager.mu.RLock()
+	requires := make(map[string][]string, len(deps))
+	for id := range deps {
+		requires[id] = []string{}
+		if manager.graph == nil {
+			continue
+		}
+		for _, requiredId := range manager.graph.Requires[id] {
+			if _, ok := deps[requiredId]; ok {
+				requires[id] = append(requires[id], requiredId)
+				continue
+			}
+			if _, ok := manager.runningDeps[requiredId]; !ok {
+				manager.mu.RUnlock()
+				return fmt.Errorf("the '%s' dependency requires '%s' that is not running", id, requiredId)
+			}
+		}
+	}
+	manager.mu.RUnlock()
+
+	resolved, err := (&Graph{Requires: requires}).Resolve()
+	if err != nil {
+		return fmt.Errorf("graph.Resolve: %w", err)
+	}
+
+	// each dependency waits for the result of the required dependencies
+	done := make(map[string]chan struct{}, len(deps))
+	for id := range deps {
+		done[id] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	started := make([]string, 0, len(deps))
+	errs := make([]error, 0)
+
+	var wg sync.WaitGroup
+	for id, dep := range deps {
+		wg.Add(1)
+		go func(id string, dep *Dep) {
+			defer wg.Done()
+			defer close(done[id])
+
+			for _, requiredId := range resolved.Requires[id] {
+				<-done[requiredId]
+			}
+
+			mu.Lock()
+			failed := len(errs) > 0
+			mu.Unlock()
+			if failed {
+				return
+			}
+
+			err := manager.Run(dep, id, optionalParent...)
+
+			mu.Lock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("manager.Run('%s'): %w", id, err))
+			}
+			// the dependency may be spawned, but not ready
+			if err == nil || !errors.Is(err, ErrAlreadyRunning) {
+				started = append(started, id)
+			}
+			mu.Unlock()
+		}(id, dep)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	startedSet := make(map[string]bool, len(started))
+	manager.mu.RLock()
+	for _, id := range started {
+		if _, ok := manager.runningDeps[id]; ok {
+			startedSet[id] = true
+		}
+	}
+	manager.mu.RUnlock()
+	stopLevels := make([][]string, 0, len(resolved.Levels))
+	for _, level := range resolved.Levels {
+		stopLevel := make([]string, 0, len(level))
+		for _, id := range level {
+			if startedSet[id] {
+				stopLevel = append(stopLevel, id)
+			}
+		}
+		stopLevels = append(stopLevels, stopLevel)
+	}
+	if err := manager.stopLevels(stopLevels); err != nil {
+		return fmt.Errorf("%w; manager.stopLevels: %v", firstError(errs), err)
+	}
+
+	return firstError(errs)
+}
+
+// StopGraph stops the running dependencies of the graph set by SetGraph in the reverse start order.
+// The dependencies on the same level are stopped in parallel.
+func (manager *DepManager) StopGraph() error {
+	resolved := manager.Graph()
+	if resolved == nil {
+		return fmt.Errorf("no graph. Call DepManager.SetGraph first")
+	}
+
+	manager.mu.RLock()
+	levels := make([][]string, 0, len(resolved.Levels))
+	for _, level := range resolved.Levels {
+		running := make([]string, 0, len(level))
+		for _, id := range level {
+			if dep, ok := manager.runningDeps[id]; ok && dep.pid > 0 {
+				running = append(running, id)
+			}
+		}
+		levels = append(levels, running)
+	}
+	manager.mu.RUnlock()
+
+	return manager.stopLevels(levels)
+}
+
+// stopLevels stops the dependencies from the last level to the first one
+func (manager *DepManager) stopLevels(levels [][]string) error {
+	errs := make([]error, 0)
+	for i := len(levels) - 1; i >= 0; i-- {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, id := range levels[i] {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				if _, err := manager.Stop(id); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("manager.Stop('%s'): %w", id, err))
+					mu.Unlock()
+				}
+			}(id)
+		}
+		wg.Wait()
+	}
+
+	return firstError(errs)
+}
+
+// firstError returns the first error with the amount of the others, or nil if there are no errors
+func firstError(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	return fmt.Errorf("%w (and %d more errors)", errs[0], len(errs)-1)
+}
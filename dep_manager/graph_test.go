@@ -0,0 +1,103 @@
+package dep_manager
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestGraphSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestGraphSuite) SetupTest() {
+	test.manager = New()
+}
+
+// Test_10_Resolve tests the start order of the graph
+func (test *TestGraphSuite) Test_10_Resolve() {
+	s := test.Require
+
+	var graph *Graph
+	s().Error(graph.IsValid())
+
+	// the proxy requires the db and cache, the cache requires the db as well
+	graph = NewGraph().
+		Require("proxy", "db", "cache").
+		Require("cache", "db", "db").
+		Require("auth")
+	resolved, err := graph.Resolve()
+	s().NoError(err)
+	s().Equal([][]string{{"auth", "db"}, {"cache"}, {"proxy"}}, resolved.Levels)
+	s().Equal([]string{"auth", "db", "cache", "proxy"}, resolved.Order)
+	// the duplicates are removed
+	s().Equal([]string{"db"}, resolved.Requires["cache"])
+
+	// the dependency requires itself
+	s().Error(NewGraph().Require("db", "db").IsValid())
+
+	// the unknown dependency
+	graph = &Graph{Requires: map[string][]string{"proxy": {"db"}}}
+	s().Error(graph.IsValid())
+
+	// the empty id
+	s().Error(NewGraph().Require("").IsValid())
+}
+
+// Test_11_Cycle tests that the path of the cycle is returned
+func (test *TestGraphSuite) Test_11_Cycle() {
+	s := test.Require
+
+	graph := NewGraph().
+		Require("proxy", "auth").
+		Require("auth", "db").
+		Require("db", "auth")
+	_, err := graph.Resolve()
+	s().Error(err)
+	s().Contains(err.Error(), "auth -> db -> auth")
+
+	s().Error(test.manager.SetGraph(graph))
+	s().Nil(test.manager.Graph())
+}
+
+// Test_12_RunGraph tests the validation of the graph before the start
+func (test *TestGraphSuite) Test_12_RunGraph() {
+	s := test.Require
+
+	graph := NewGraph().Require("proxy", "db")
+	s().NoError(test.manager.SetGraph(graph))
+
+	// the graph is copied
+	graph.Require("proxy", "cache")
+	resolved := test.manager.Graph()
+	s().NotNil(resolved)
+	s().Equal([]string{"db", "proxy"}, resolved.Order)
+
+	s().Error(test.manager.RunGraph(nil))
+
+	// the required dependency is not running
+	err := test.manager.RunGraph(map[string]*Dep{"proxy": {}})
+	s().Error(err)
+	s().Contains(err.Error(), "'db'")
+
+	// the dependencies are not linted, so the proxy is not started after the failed db
+	err = test.manager.RunGraph(map[string]*Dep{"proxy": {}, "db": {}})
+	s().ErrorIs(err, ErrNotLinted)
+	s().Contains(err.Error(), "'db'")
+	s().NotContains(err.Error(), "'proxy'")
+	s().Empty(test.manager.List())
+
+	// nothing is running to stop
+	s().NoError(test.manager.StopGraph())
+	s().Error(New().StopGraph())
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestGraph(t *testing.T) {
+	suite.Run(t, new(TestGraphSuite))
+}
@@ -0,0 +1,254 @@
+package dep_manager
+
+import "fmt"
+
+// Strategy defines which members of the group are restarted when one of them exits.
+// The strategies follow the Erlang supervisors.
+type Strategy = string
+
+const (
+	OneForOne  Strategy = "one-for-one"  // only the exited member is restarted
+	OneForAll  Strategy = "one-for-all"  // all members are stopped and restarted
+	RestForOne Strategy = "rest-for-one" // the exited member and the members listed after it are restarted
+)
+
+// A Group is the set of dependencies that only work together.
+//
+// The Members are listed in the start order.
+// The members are restarted by their own RestartPolicy.
+// If the member has no policy, then the Policy of the group is used.
+type Group struct {
+	Id       string         `json:"id"`
+	Strategy Strategy       `json:"strategy"`
+	Members  []string       `json:"members"`
+	Policy   *RestartPolicy `json:"policy,omitempty"`
+}
+
+// NewGroup returns a group of the dependencies by their ids.
+func NewGroup(id string, strategy Strategy, members ...string) *Group {
+	return &Group{
+		Id:       id,
+		Strategy: strategy,
+		Members:  members,
+	}
+}
+
+// IsValid returns an error if the group has unknown strategy, or no members
+func (group *Group) IsValid() error {
+	if group == nil {
+		return fmt.Errorf("nil")
+	}
+	if len(group.Id) == 0 {
+		return fmt.Errorf("no id")
+	}
+	if group.Strategy != OneForOne && group.Strategy != OneForAll && group.Strategy != RestForOne {
+		return fmt.Errorf("unknown '%s' strategy, expected '%s', '%s' or '%s'",
+			group.Strategy, OneForOne, OneForAll, RestForOne)
+	}
+	if len(group.Members) == 0 {
+		return fmt.Errorf("no members")
+	}
+
+	members := make(map[string]bool, len(group.Members))
+	for _, member := range group.Members {
+		if len(member) == 0 {
+			return fmt.Errorf("empty member id")
+		}
+		if members[member] {
+			return fmt.Errorf("duplicate '%s' member", member)
+		}
+		members[member] = true
+	}
+
+	if group.Policy != nil {
+		if err := group.Policy.IsValid(); err != nil {
+			return fmt.Errorf("group.Policy.IsValid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Has returns true if the dependency by its id is the member of the group
+func (group *Group) Has(id string) bool {
+	for _, member := range group.Members {
+		if member == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// siblings returns the members to restart with the exited member in the start order.
+// The result includes the exited member.
+func (group *Group) siblings(id string) []string {
+	switch group.Strategy {
+	case OneForAll:
+		return group.Members
+	case RestForOne:
+		for i, member := range group.Members {
+			if member == id {
+				return group.Members[i:]
+			}
+		}
+	}
+
+	return []string{id}
+}
+
+// SetGroup adds the group of the dependencies.
+// If the group with the same id exists, then it's replaced.
+// The dependency could be the member of one group only.
+func (manager *DepManager) SetGroup(group *Group) error {
+	if manager == nil {
+		return fmt.Errorf("nil")
+	}
+	if err := group.IsValid(); err != nil {
+		return fmt.Errorf("group.IsValid: %w", err)
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	for _, member := range group.Members {
+		memberGroup := manager.memberGroup(member)
+		if memberGroup != nil && memberGroup.Id != group.Id {
+			return fmt.Errorf("the '%s' member is in the '%s' group", member, memberGroup.Id)
+		}
+	}
+
+	manager.groups[group.Id] = group
+
+	return nil
+}
+
+// RemoveGroup deletes the group. The members are not stopped.
+func (manager *DepManager) RemoveGroup(id string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	delete(manager.groups, id)
+}
+
+// Group returns the group by its id or nil if it's not found
+func (manager *DepManager) Group(id string) *Group {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.groups[id]
+}
+
+// memberGroup returns the group of the dependency or nil.
+// The caller must lock the DepManager.
+func (manager *DepManager) memberGroup(id string) *Group {
+	for _, group := range manager.groups {
+		if group.Has(id) {
+			return group
+		}
+	}
+
+	return nil
+}
+
+// restartPolicy returns the policy of the dependency.
+// If the dependency has no policy, then the policy of its group is returned.
+// The caller must lock the DepManager.
+func (manager *DepManager) restartPolicy(id string) *RestartPolicy {
+	if policy, ok := manager.policies[id]; ok {
+		return policy
+	}
+	if group := manager.memberGroup(id); group != nil {
+		return group.Policy
+	}
+
+	return nil
+}
+
+// killSibling kills the process group of the sibling stopped by the group.
+// The tests replace it to simulate the failed kill.
+var killSibling = killProcessGroup
+
+// stopSiblings kills the running members of the group that must be restarted with the exited dependency.
+// Only the members spawned by this DepManager are restarted.
+//
+// Returns the ids to restart in the start order, including the exited dependency.
+func (manager *DepManager) stopSiblings(id string) []string {
+	manager.mu.RLock()
+	group := manager.memberGroup(id)
+	members := []string{id}
+	if group != nil {
+		members = group.siblings(id)
+	}
+	manager.mu.RUnlock()
+
+	restarts := make([]string, 0, len(members))
+	for _, member := range members {
+		if member == id {
+			restarts = append(restarts, member)
+			continue
+		}
+
+		manager.mu.Lock()
+		sibling, ok := manager.runningDeps[member]
+		if !ok || sibling.cmd == nil || sibling.stopping || sibling.respawn != nil {
+			manager.mu.Unlock()
+			continue
+		}
+		exited := make(chan error, 1)
+		sibling.siblingExit = exited
+		pid := sibling.pid
+		startTime := sibling.startTime
+		stderr := sibling.stderr
+		manager.mu.Unlock()
+
+		// the children of the sibling are killed along with it
+		killErr := killSibling(pid)
+		if killErr == nil {
+			<-exited
+		}
+
+		manager.mu.Lock()
+		sibling.siblingExit = nil
+		manager.mu.Unlock()
+
+		if killErr == nil {
+			restarts = append(restarts, member)
+			continue
+		}
+
+		// the sibling that wasn't killed is not restarted with the group.
+		// if it exited meanwhile, then it's handled as any exited dependency,
+		// otherwise its own wait handles the exit.
+		select {
+		case exitErr := <-exited:
+			go manager.exit(member, sibling, startTime, stderr, exitErr)
+		default:
+		}
+	}
+
+	return restarts
+}
+
+// restartSibling spawns the member stopped by stopSiblings again.
+// If the member fails to start, then it's removed from the running dependencies.
+func (manager *DepManager) restartSibling(id string) {
+	manager.mu.RLock()
+	instance := manager.runningDeps[id]
+	stopping := instance.stopping
+	manager.mu.RUnlock()
+
+	if stopping {
+		manager.release(id, instance, nil)
+		return
+	}
+
+	if err := manager.start(id, instance); err != nil {
+		manager.emit(&Event{Id: id, Type: EventFailed, Err: fmt.Errorf("manager.start: %w", err)})
+		manager.release(id, instance, err)
+		return
+	}
+
+	manager.emit(&Event{Id: id, Type: EventRestarted})
+	manager.wait(id)
+}
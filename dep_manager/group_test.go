@@ -0,0 +1,110 @@
+package dep_manager
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestGroupSuite struct {
+	suite.Suite
+}
+
+// Test_10_IsValid tests the validation of the group
+func (test *TestGroupSuite) Test_10_IsValid() {
+	s := test.Require
+
+	var group *Group
+	s().Error(group.IsValid())
+
+	// no id
+	group = NewGroup("", OneForOne, "db")
+	s().Error(group.IsValid())
+
+	// unknown strategy
+	group = NewGroup("storage", "one-for-some", "db")
+	s().Error(group.IsValid())
+
+	// no members
+	group = NewGroup("storage", OneForAll)
+	s().Error(group.IsValid())
+
+	// duplicate members
+	group = NewGroup("storage", OneForAll, "db", "db")
+	s().Error(group.IsValid())
+
+	// invalid policy
+	group = NewGroup("storage", OneForAll, "db", "auth")
+	group.Policy = NewRestartPolicy("sometimes")
+	s().Error(group.IsValid())
+
+	group.Policy = NewRestartPolicy(RestartOnFailure)
+	s().NoError(group.IsValid())
+}
+
+// Test_11_Siblings tests the members restarted by each strategy
+func (test *TestGroupSuite) Test_11_Siblings() {
+	s := test.Require
+
+	group := NewGroup("storage", OneForOne, "db", "auth", "cache")
+	s().Equal([]string{"auth"}, group.siblings("auth"))
+
+	group.Strategy = OneForAll
+	s().Equal([]string{"db", "auth", "cache"}, group.siblings("auth"))
+
+	group.Strategy = RestForOne
+	s().Equal([]string{"auth", "cache"}, group.siblings("auth"))
+	s().Equal([]string{"cache"}, group.siblings("cache"))
+	s().Equal([]string{"db", "auth", "cache"}, group.siblings("db"))
+}
+
+// Test_12_SetGroup tests adding, replacing and removing the groups
+func (test *TestGroupSuite) Test_12_SetGroup() {
+	s := test.Require
+
+	manager := New()
+
+	group := NewGroup("storage", OneForAll, "db", "auth")
+	group.Policy = NewRestartPolicy(RestartAlways)
+	s().NoError(manager.SetGroup(group))
+	s().Equal(group, manager.Group("storage"))
+
+	// the member's policy is taken from the group
+	s().Equal(group.Policy, manager.restartPolicy("db"))
+	s().Nil(manager.restartPolicy("cache"))
+
+	// the own policy of the member has a priority
+	policy := NewRestartPolicy(RestartOnFailure)
+	s().NoError(manager.SetRestartPolicy("db", policy))
+	s().Equal(policy, manager.restartPolicy("db"))
+
+	// the member can't be in the two groups
+	s().Error(manager.SetGroup(NewGroup("auth", OneForOne, "auth")))
+
+	// replacing the group
+	s().NoError(manager.SetGroup(NewGroup("storage", RestForOne, "db", "auth", "cache")))
+	s().Equal(RestForOne, manager.Group("storage").Strategy)
+
+	manager.RemoveGroup("storage")
+	s().Nil(manager.Group("storage"))
+	s().NoError(manager.SetGroup(NewGroup("auth", OneForOne, "auth")))
+}
+
+// Test_13_StopSiblings tests that the not running members are not restarted
+func (test *TestGroupSuite) Test_13_StopSiblings() {
+	s := test.Require
+
+	manager := New()
+	s().Equal([]string{"db"}, manager.stopSiblings("db"))
+
+	s().NoError(manager.SetGroup(NewGroup("storage", OneForAll, "db", "auth", "cache")))
+	s().Equal([]string{"auth"}, manager.stopSiblings("auth"))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestGroup(t *testing.T) {
+	suite.Run(t, new(TestGroupSuite))
+}
@@ -0,0 +1,247 @@
+package dep_manager
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"sync"
+	"time"
+)
+
+// Health is the state of the dependency by its heartbeats
+type Health = string
+
+const (
+	HealthUnknown      Health = "unknown"      // the dependency was not checked yet
+	HealthHealthy      Health = "healthy"      // the dependency replies in time
+	HealthDegraded     Health = "degraded"     // the dependency replies slowly, or it missed a few heartbeats
+	HealthUnresponsive Health = "unresponsive" // the dependency missed HealthPolicy.MaxFailures heartbeats in a row
+)
+
+const (
+	// DefaultHealthInterval is the interval between the heartbeats of the health monitor
+	DefaultHealthInterval = time.Second * 5
+	// DefaultSlowLatency is the heartbeat latency after which the dependency is degraded
+	DefaultSlowLatency = time.Millisecond * 500
+	// DefaultMaxFailures is the amount of the missed heartbeats in a row after which the dependency is unresponsive
+	DefaultMaxFailures = 3
+)
+
+// A HealthPolicy defines how the health monitor checks the running dependencies
+type HealthPolicy struct {
+	Interval    time.Duration `json:"interval"`
+	SlowLatency time.Duration `json:"slow_latency"`
+	MaxFailures uint64        `json:"max_failures"`
+	// Restart kills the unresponsive dependency whose process is still alive,
+	// so it's restarted by its RestartPolicy. Only the dependencies spawned by the DepManager are killed.
+	Restart bool `json:"restart"`
+}
+
+// A HealthStatus is the result of the last heartbeats of the dependency
+type HealthStatus struct {
+	Id        string        `json:"id"`
+	Health    Health        `json:"health"`
+	Latency   time.Duration `json:"latency"`  // the round trip of the last successful heartbeat
+	Failures  uint64        `json:"failures"` // the missed heartbeats in a row
+	LastCheck time.Time     `json:"last_check"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
+// The healthMonitor heartbeats the running dependencies in the background
+type healthMonitor struct {
+	policy *HealthPolicy
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewHealthPolicy returns the policy with the default limits that doesn't restart the dependencies
+func NewHealthPolicy() *HealthPolicy {
+	return &HealthPolicy{
+		Interval:    DefaultHealthInterval,
+		SlowLatency: DefaultSlowLatency,
+		MaxFailures: DefaultMaxFailures,
+	}
+}
+
+// IsValid returns an error if the limits are not positive
+func (policy *HealthPolicy) IsValid() error {
+	if policy == nil {
+		return fmt.Errorf("nil")
+	}
+	if policy.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if policy.SlowLatency <= 0 {
+		return fmt.Errorf("slow latency must be positive")
+	}
+	if policy.MaxFailures == 0 {
+		return fmt.Errorf("max failures must be positive")
+	}
+
+	return nil
+}
+
+// classify returns the health by the missed heartbeats and the latency
+func (policy *HealthPolicy) classify(failures uint64, latency time.Duration) Health {
+	if failures >= policy.MaxFailures {
+		return HealthUnresponsive
+	}
+	if failures > 0 || latency > policy.SlowLatency {
+		return HealthDegraded
+	}
+	return HealthHealthy
+}
+
+// StartHealthMonitor heartbeats the spawned and adopted dependencies that have the socket parameters.
+// See Dep.SetManager.
+//
+// When the health of the dependency changes, the EventHealth is emitted.
+// If the monitor is running already, then it's restarted with the new policy.
+func (manager *DepManager) StartHealthMonitor(policy *HealthPolicy) error {
+	if manager == nil {
+		return fmt.Errorf("nil")
+	}
+	if err := policy.IsValid(); err != nil {
+		return fmt.Errorf("policy.IsValid: %w", err)
+	}
+
+	manager.StopHealthMonitor()
+
+	monitor := &healthMonitor{
+		policy: policy,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	manager.mu.Lock()
+	manager.monitor = monitor
+	manager.mu.Unlock()
+
+	go manager.monitorHealth(monitor)
+
+	return nil
+}
+
+// StopHealthMonitor stops the health monitor, and waits until its last check is finished
+func (manager *DepManager) StopHealthMonitor() {
+	if manager == nil {
+		return
+	}
+
+	manager.mu.Lock()
+	monitor := manager.monitor
+	manager.monitor = nil
+	manager.mu.Unlock()
+
+	if monitor != nil {
+		close(monitor.stop)
+		<-monitor.done
+	}
+}
+
+// HealthStatus returns the health of the running dependency.
+// If the dependency was not checked yet, then it's HealthUnknown.
+func (manager *DepManager) HealthStatus(id string) *HealthStatus {
+	status := &HealthStatus{Id: id, Health: HealthUnknown}
+	if manager == nil {
+		return status
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	if last, ok := manager.health[id]; ok {
+		*status = *last
+	}
+
+	return status
+}
+
+// monitorHealth checks the dependencies every interval until the monitor is stopped
+func (manager *DepManager) monitorHealth(monitor *healthMonitor) {
+	defer close(monitor.done)
+
+	ticker := time.NewTicker(monitor.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-monitor.stop:
+			return
+		case <-ticker.C:
+			manager.checkHealth(monitor.policy)
+		}
+	}
+}
+
+// checkHealth heartbeats the running dependencies in parallel
+func (manager *DepManager) checkHealth(policy *HealthPolicy) {
+	manager.mu.Lock()
+	targets := make(map[string]*clientConfig.Client, len(manager.runningDeps))
+	for id, dep := range manager.runningDeps {
+		if dep.manager != nil && dep.pid > 0 && !dep.stopping {
+			targets[id] = dep.manager
+		}
+	}
+	// the stopped dependencies
+	for id := range manager.health {
+		if _, ok := targets[id]; !ok {
+			delete(manager.health, id)
+		}
+	}
+	manager.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for id, c := range targets {
+		wg.Add(1)
+		go func(id string, c *clientConfig.Client) {
+			defer wg.Done()
+			latency, err := manager.heartbeat(c)
+			manager.updateHealth(policy, id, latency, err)
+		}(id, c)
+	}
+	wg.Wait()
+}
+
+// updateHealth records the heartbeat result.
+// If the health changed, then the EventHealth is emitted.
+// The unresponsive dependency with the alive process is killed if the policy restarts it.
+func (manager *DepManager) updateHealth(policy *HealthPolicy, id string, latency time.Duration, err error) {
+	manager.mu.Lock()
+	dep, running := manager.runningDeps[id]
+	if !running {
+		manager.mu.Unlock()
+		return
+	}
+	status, ok := manager.health[id]
+	if !ok {
+		status = &HealthStatus{Id: id, Health: HealthUnknown}
+		manager.health[id] = status
+	}
+	status.LastCheck = time.Now()
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+	} else {
+		status.Failures = 0
+		status.LastError = ""
+		status.Latency = latency
+	}
+	previous := status.Health
+	status.Health = policy.classify(status.Failures, latency)
+	health := status.Health
+
+	kill := health == HealthUnresponsive && policy.Restart && dep.cmd != nil && dep.respawn == nil && processAlive(dep.pid)
+	pid := dep.pid
+	if kill {
+		// the next instance is checked from scratch
+		delete(manager.health, id)
+	}
+	manager.mu.Unlock()
+
+	if health != previous {
+		manager.emit(&Event{Id: id, Type: EventHealth, Health: health, Err: err})
+	}
+	if kill {
+		// the exit is handled by the restart policy
+		_ = killProcessGroup(pid)
+	}
+}
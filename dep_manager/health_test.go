@@ -0,0 +1,114 @@
+package dep_manager
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestHealthSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestHealthSuite) SetupTest() {
+	test.manager = New()
+}
+
+// Test_10_Policy tests the validation of the policy and the health by the heartbeats
+func (test *TestHealthSuite) Test_10_Policy() {
+	s := test.Require
+
+	var nilPolicy *HealthPolicy
+	s().Error(nilPolicy.IsValid())
+
+	policy := NewHealthPolicy()
+	s().NoError(policy.IsValid())
+	s().False(policy.Restart)
+
+	policy.MaxFailures = 0
+	s().Error(policy.IsValid())
+	policy.MaxFailures = 2
+
+	s().Equal(HealthHealthy, policy.classify(0, time.Millisecond))
+	s().Equal(HealthDegraded, policy.classify(0, policy.SlowLatency+time.Millisecond))
+	s().Equal(HealthDegraded, policy.classify(1, time.Millisecond))
+	s().Equal(HealthUnresponsive, policy.classify(2, time.Millisecond))
+
+	s().Error(test.manager.StartHealthMonitor(&HealthPolicy{}))
+	s().NoError(test.manager.StartHealthMonitor(policy))
+	test.manager.StopHealthMonitor()
+	// stopping twice is not an error
+	test.manager.StopHealthMonitor()
+}
+
+// Test_11_Update tests the health transitions and the emitted events
+func (test *TestHealthSuite) Test_11_Update() {
+	s := test.Require
+
+	id := "dep"
+	policy := NewHealthPolicy()
+	policy.MaxFailures = 2
+
+	status := test.manager.HealthStatus(id)
+	s().Equal(HealthUnknown, status.Health)
+
+	// the dependency that is not running is not recorded
+	test.manager.updateHealth(policy, id, time.Millisecond, nil)
+	s().Equal(HealthUnknown, test.manager.HealthStatus(id).Health)
+
+	test.manager.runningDeps[id] = &Dep{pid: 1, exited: make(chan struct{})}
+	events := test.manager.OnEvent(id)
+
+	test.manager.updateHealth(policy, id, time.Millisecond, nil)
+	status = test.manager.HealthStatus(id)
+	s().Equal(HealthHealthy, status.Health)
+	s().Equal(time.Millisecond, status.Latency)
+	s().False(status.LastCheck.IsZero())
+	event := <-events
+	s().Equal(EventHealth, event.Type)
+	s().Equal(HealthHealthy, event.Health)
+
+	// the same health is not emitted twice
+	test.manager.updateHealth(policy, id, time.Millisecond*2, nil)
+	s().Len(events, 0)
+
+	test.manager.updateHealth(policy, id, 0, fmt.Errorf("timeout"))
+	status = test.manager.HealthStatus(id)
+	s().Equal(HealthDegraded, status.Health)
+	s().Equal(uint64(1), status.Failures)
+	s().Equal("timeout", status.LastError)
+	event = <-events
+	s().Equal(HealthDegraded, event.Health)
+	s().Error(event.Err)
+
+	test.manager.updateHealth(policy, id, 0, fmt.Errorf("timeout"))
+	s().Equal(HealthUnresponsive, test.manager.HealthStatus(id).Health)
+	event = <-events
+	s().Equal(HealthUnresponsive, event.Health)
+
+	// the dependency recovered
+	test.manager.updateHealth(policy, id, time.Millisecond, nil)
+	status = test.manager.HealthStatus(id)
+	s().Equal(HealthHealthy, status.Health)
+	s().Zero(status.Failures)
+	s().Empty(status.LastError)
+	event = <-events
+	s().Equal(HealthHealthy, event.Health)
+
+	// the statuses of the stopped dependencies are deleted
+	delete(test.manager.runningDeps, id)
+	test.manager.checkHealth(policy)
+	s().Equal(HealthUnknown, test.manager.HealthStatus(id).Health)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(TestHealthSuite))
+}
@@ -0,0 +1,88 @@
+package dep_manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A DepInfo describes the dependency spawned or adopted by the DepManager
+type DepInfo struct {
+	Id        string        `json:"id"`
+	Pid       int           `json:"pid"`
+	Url       string        `json:"url"`
+	BinPath   string        `json:"bin_path"`
+	Args      []string      `json:"args"`
+	Detached  bool          `json:"detached"`
+	StartTime time.Time     `json:"start_time"`
+	Uptime    time.Duration `json:"uptime"`              // the time since the last start
+	Restarts  uint64        `json:"restarts"`            // total amount of the restarts since DepManager.Run
+	LastExit  *ExitStatus   `json:"last_exit,omitempty"` // the exit before the last restart
+}
+
+// Info returns the running dependency by its id
+func (manager *DepManager) Info(id string) (*DepInfo, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("nil")
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	dep, ok := manager.runningDeps[id]
+	if !ok || dep.pid == 0 {
+		return nil, fmt.Errorf("the '%s' dep is not running", id)
+	}
+
+	return manager.info(id, dep, time.Now()), nil
+}
+
+// List returns the running dependencies sorted by their id
+func (manager *DepManager) List() []*DepInfo {
+	if manager == nil {
+		return []*DepInfo{}
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	now := time.Now()
+	infos := make([]*DepInfo, 0, len(manager.runningDeps))
+	for id, dep := range manager.runningDeps {
+		// the dependency is being started
+		if dep.pid == 0 {
+			continue
+		}
+		infos = append(infos, manager.info(id, dep, now))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Id < infos[j].Id
+	})
+
+	return infos
+}
+
+// The info returns the description of the dependency.
+// The caller must lock the DepManager for reading.
+func (manager *DepManager) info(id string, dep *Dep, now time.Time) *DepInfo {
+	info := &DepInfo{
+		Id:        id,
+		Pid:       dep.pid,
+		BinPath:   dep.binPath,
+		Args:      append([]string{}, dep.args...),
+		Detached:  dep.detached,
+		StartTime: dep.startTime,
+	}
+	if dep.Src != nil {
+		info.Url = dep.Url
+	}
+	if !dep.startTime.IsZero() {
+		info.Uptime = now.Sub(dep.startTime)
+	}
+	if sup, ok := manager.supervisors[id]; ok {
+		info.Restarts = sup.total
+		info.LastExit = sup.lastExit
+	}
+
+	return info
+}
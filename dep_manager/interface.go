@@ -1,14 +1,13 @@
 package dep_manager
 
 import (
+	"context"
 	clientConfig "github.com/ahmetson/client-lib/config"
 	"github.com/ahmetson/log-lib"
+	"time"
 )
 
 // The Interface of the dependency manager.
-//
-// It doesn't have the `Stop` command.
-// Because, stopping must be done by the remote call from other services.
 type Interface interface {
 	// Installed checks is the service binary exists
 	Installed(dep *Dep) bool
@@ -16,6 +15,24 @@ type Interface interface {
 	// Install the dependency from the source code. It compiles it.
 	Install(dep *Dep, logger *log.Logger) error
 
+	// InstallContext installs the dependency. The clone and build are stopped when the context is done.
+	InstallContext(ctx context.Context, dep *Dep, logger *log.Logger) error
+
+	// Update fetches the latest commit of the dependency, and rebuilds it. The previous binary is restored if the build fails.
+	Update(dep *Dep, logger *log.Logger) error
+
+	// UpdateContext updates the dependency. The fetch and build are stopped when the context is done.
+	UpdateContext(ctx context.Context, dep *Dep, logger *log.Logger) error
+
+	// Rollback installs the binary built before the installed one
+	Rollback(dep *Dep) (*BinVersion, error)
+
+	// SetBuildMode sets how the dependency by its url treats its modules during the build. Empty mode is the default
+	SetBuildMode(url string, mode BuildMode) error
+
+	// UpdateLock sets the locked commit of the dependency by its url to its branch head. Empty url updates all.
+	UpdateLock(ctx context.Context, url string) ([]*LockEntry, error)
+
 	// Run the dependency with the given id and parent.
 	Run(dep *Dep, id string, optionalParent ...*clientConfig.Client) error
 	// Uninstall the dependency.
@@ -27,6 +44,57 @@ type Interface interface {
 	// Running checks is the service running or not
 	Running(*clientConfig.Client) (bool, error)
 
+	// Probe sends the heartbeat, and returns the latency, the failure reason and the metadata of the dependency
+	Probe(*clientConfig.Client) (*ProbeResult, error)
+
 	// Close the given dependency service
 	Close(c *clientConfig.Client) error
+
+	// WaitReady waits until the running dependency replies to the heartbeat
+	WaitReady(id string, c *clientConfig.Client, timeout time.Duration) error
+
+	// HealthStatus returns the health of the running dependency by the health monitor
+	HealthStatus(id string) *HealthStatus
+
+	// Stop the dependency spawned by the DepManager by its id
+	Stop(id string) (*ExitStatus, error)
+
+	// List returns the running dependencies
+	List() []*DepInfo
+
+	// Info returns the running dependency by its id
+	Info(id string) (*DepInfo, error)
+
+	// Logs returns the last lines of the dependency output
+	Logs(id string, lines int) ([]string, error)
+
+	// CrashReports returns the last exits of the dependency
+	CrashReports(id string) []*CrashReport
+
+	// LogsFrom returns the lines of the dependency output after the offset, and the next offset
+	LogsFrom(id string, offset int64) ([]string, int64, error)
+
+	// SetRestartPolicy sets the policy to restart the exited dependency by its id
+	SetRestartPolicy(id string, policy *RestartPolicy) error
+
+	// RestartStatus returns the restarts of the dependency by its id
+	RestartStatus(id string) *RestartStatus
+
+	// SetGroup adds the dependencies that are restarted together by the group strategy
+	SetGroup(group *Group) error
+
+	// RemoveGroup deletes the group by its id
+	RemoveGroup(id string)
+
+	// SetGraph sets the dependencies between the dependencies by their ids
+	SetGraph(graph *Graph) error
+
+	// Graph returns the start order of the graph set by SetGraph, or nil if there is no graph
+	Graph() *ResolvedGraph
+
+	// RunGraph runs the dependencies by their ids in the order of the graph
+	RunGraph(deps map[string]*Dep, optionalParent ...*clientConfig.Client) error
+
+	// StopGraph stops the running dependencies of the graph in the reverse order
+	StopGraph() error
 }
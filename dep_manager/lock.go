@@ -0,0 +1,120 @@
+package dep_manager
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// A call is the in-flight operation shared by the concurrent callers with the same key
+type call struct {
+	done     chan struct{}
+	err      error
+	waiters  int                // the callers waiting for the call, the call is canceled when it's 0
+	cancel   context.CancelFunc // stops the call
+	progress *sharedProgress    // passes the progress of the call to the waiting callers
+}
+
+// lockPaths locks the files or directories, so that the dependencies
+// sharing the source code or binary are not built at the same time.
+// The paths are locked in the sorted order to avoid the deadlocks.
+//
+// Returns the function to unlock the paths.
+func (manager *DepManager) lockPaths(paths ...string) func() {
+	sorted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if len(p) > 0 {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Strings(sorted)
+
+	locks := make([]*sync.Mutex, 0, len(sorted))
+	manager.mu.Lock()
+	for i, p := range sorted {
+		if i > 0 && sorted[i-1] == p {
+			continue
+		}
+		lock, ok := manager.pathLocks[p]
+		if !ok {
+			lock = &sync.Mutex{}
+			manager.pathLocks[p] = lock
+		}
+		locks = append(locks, lock)
+	}
+	manager.mu.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// deduplicate calls the function once for the concurrent callers with the same key.
+// The callers that came while the function is running wait for it, and get the same error.
+// The progress passed to the function reaches the progress of every waiting caller,
+// and the failing progress of one caller doesn't change the result of the others.
+//
+// The function runs with its own context, so it's not stopped by the context of the caller that started it.
+// If the context of the waiting caller is done, then the caller stops waiting with the context error.
+// When the last caller stops waiting, the function's context is canceled,
+// and the caller returns after the function is stopped.
+func (manager *DepManager) deduplicate(ctx context.Context, key string, progress Progress, fn func(ctx context.Context, progress Progress) error) error {
+	manager.mu.Lock()
+	c, ok := manager.calls[key]
+	for ok && c.waiters == 0 {
+		// the canceled call is finishing, then the new call is started
+		manager.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return contextError(ctx)
+		}
+		manager.mu.Lock()
+		c, ok = manager.calls[key]
+	}
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel, progress: newSharedProgress()}
+		manager.calls[key] = c
+		go manager.runCall(callCtx, key, c, fn)
+	}
+	c.waiters++
+	manager.mu.Unlock()
+	receiver := c.progress.add(progress)
+
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+	}
+
+	c.progress.remove(receiver)
+	manager.mu.Lock()
+	c.waiters--
+	last := c.waiters == 0
+	manager.mu.Unlock()
+	if last {
+		c.cancel()
+		// the partial result of the call is cleaned up when it's stopped
+		<-c.done
+	}
+
+	return contextError(ctx)
+}
+
+// runCall calls the shared function, and notifies the waiting callers
+func (manager *DepManager) runCall(ctx context.Context, key string, c *call, fn func(ctx context.Context, progress Progress) error) {
+	c.err = fn(ctx, c.progress)
+	c.cancel()
+
+	manager.mu.Lock()
+	delete(manager.calls, key)
+	manager.mu.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,317 @@
+package dep_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestLockSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestLockSuite) SetupTest() {
+	test.manager = New()
+}
+
+// Test_10_Deduplicate tests that the concurrent calls with the same key run the function once
+func (test *TestLockSuite) Test_10_Deduplicate() {
+	s := test.Require
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(context.Context, Progress) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return fmt.Errorf("build failed")
+	}
+
+	amount := 10
+	errs := make(chan error, amount)
+	go func() {
+		errs <- test.manager.deduplicate(context.Background(), "dep", nil, fn)
+	}()
+	<-started
+	for i := 1; i < amount; i++ {
+		go func() {
+			errs <- test.manager.deduplicate(context.Background(), "dep", nil, fn)
+		}()
+	}
+
+	// wait a bit for the callers to join the in-flight call
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+
+	for i := 0; i < amount; i++ {
+		s().EqualError(<-errs, "build failed")
+	}
+	s().Equal(int32(1), atomic.LoadInt32(&calls))
+
+	// after the call is finished, the function is called again
+	s().Error(test.manager.deduplicate(context.Background(), "dep", nil, fn))
+	s().Equal(int32(2), atomic.LoadInt32(&calls))
+}
+
+// Test_11_DeduplicateKeys tests that the calls with the different keys run in parallel
+func (test *TestLockSuite) Test_11_DeduplicateKeys() {
+	s := test.Require
+
+	var wg sync.WaitGroup
+	var running int32
+	both := make(chan struct{})
+	fn := func(context.Context, Progress) error {
+		if atomic.AddInt32(&running, 1) == 2 {
+			close(both)
+		}
+		select {
+		case <-both:
+			return nil
+		case <-time.After(time.Second * 5):
+			return fmt.Errorf("not parallel")
+		}
+	}
+
+	errs := make([]error, 2)
+	for i, key := range []string{"dep_1", "dep_2"} {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			errs[i] = test.manager.deduplicate(context.Background(), key, nil, fn)
+		}(i, key)
+	}
+	wg.Wait()
+
+	s().NoError(errs[0])
+	s().NoError(errs[1])
+}
+
+// Test_12_LockPaths tests that the shared paths are locked, and the duplicate paths are locked once
+func (test *TestLockSuite) Test_12_LockPaths() {
+	s := test.Require
+
+	unlock := test.manager.lockPaths("src", "bin", "src", "")
+
+	locked := make(chan struct{})
+	go func() {
+		innerUnlock := test.manager.lockPaths("bin")
+		close(locked)
+		innerUnlock()
+	}()
+
+	select {
+	case <-locked:
+		s().Fail("the path must be locked")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	// the other paths are not locked
+	test.manager.lockPaths("other")()
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		s().Fail("the path must be unlocked")
+	}
+}
+
+// Test_13_Concurrent accesses the manager from the multiple goroutines.
+// Run it with the race detector.
+func (test *TestLockSuite) Test_13_Concurrent() {
+	// the require must not be called out of the test goroutine
+	a := test.Assert
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("dep_%d", i)
+			a().NoError(test.manager.SetRestartPolicy(id, NewRestartPolicy(RestartOnFailure)))
+			_ = test.manager.RestartStatus(id)
+			_ = test.manager.OnEvent(id)
+			_ = test.manager.OnStop(id)
+			a().NoError(test.manager.SetGroup(NewGroup(id, OneForOne, id)))
+			_ = test.manager.Group(id)
+			test.manager.emit(&Event{Id: id, Type: EventRestarted})
+			test.manager.RemoveGroup(id)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Test_14_DeduplicateContext tests that the waiting caller stops when its context is done
+func (test *TestLockSuite) Test_14_DeduplicateContext() {
+	s := test.Require
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- test.manager.deduplicate(context.Background(), "dep", nil, func(context.Context, Progress) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err := test.manager.deduplicate(ctx, "dep", nil, func(context.Context, Progress) error {
+		return fmt.Errorf("must not be called")
+	})
+	s().ErrorIs(err, ErrTimeout)
+
+	// the in-flight call is not affected
+	close(release)
+	s().NoError(<-errs)
+}
+
+// Test_15_DeduplicateCancel tests that the call is not stopped by the caller that started it,
+// and it's canceled when all callers stopped waiting
+func (test *TestLockSuite) Test_15_DeduplicateCancel() {
+	s := test.Require
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(ctx context.Context, _ Progress) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- test.manager.deduplicate(ctx, "dep", nil, fn)
+	}()
+	<-started
+
+	waiting := make(chan error, 1)
+	go func() {
+		waiting <- test.manager.deduplicate(context.Background(), "dep", nil, fn)
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	// the waiting caller gets the result of the call
+	cancel()
+	s().ErrorIs(<-errs, context.Canceled)
+	close(release)
+	s().NoError(<-waiting)
+
+	// the only caller cancels the call
+	started = make(chan struct{})
+	release = make(chan struct{})
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	s().ErrorIs(test.manager.deduplicate(ctx, "dep", nil, fn), ErrTimeout)
+	s().Empty(test.manager.calls)
+}
+
+// Test_16_InstallKey tests that the installations at another version or build mode are not shared
+func (test *TestLockSuite) Test_16_InstallKey() {
+	s := test.Require
+
+	newDep := func(tag string) *Dep {
+		return &Dep{
+			Src:           &source.Src{Url: "github.com/ahmetson/test-manager", Tag: tag},
+			srcPath:       "src",
+			binPath:       "bin",
+			manageableSrc: true,
+		}
+	}
+
+	s().Equal(test.manager.installKey(newDep("v1.0.0")), test.manager.installKey(newDep("v1.0.0")))
+	s().NotEqual(test.manager.installKey(newDep("v1.0.0")), test.manager.installKey(newDep("v2.0.0")))
+
+	key := test.manager.installKey(newDep(""))
+	s().NoError(test.manager.SetBuildMode("github.com/ahmetson/test-manager", BuildVendor))
+	s().NotEqual(key, test.manager.installKey(newDep("")))
+}
+
+// The jobProgress records the stages of the job, and fails when the job is canceled
+type jobProgress struct {
+	ctx    context.Context
+	mu     sync.Mutex
+	stages []Stage
+}
+
+func (progress *jobProgress) Stage(stage Stage) error {
+	if progress.ctx.Err() != nil {
+		return fmt.Errorf("job canceled")
+	}
+	progress.mu.Lock()
+	progress.stages = append(progress.stages, stage)
+	progress.mu.Unlock()
+	return nil
+}
+
+func (progress *jobProgress) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// Test_17_DeduplicateProgress tests that two jobs sharing the call get its progress,
+// and the canceled first job doesn't stop the call for the other one
+func (test *TestLockSuite) Test_17_DeduplicateProgress() {
+	s := test.Require
+
+	joined := make(chan struct{})
+	fn := func(ctx context.Context, progress Progress) error {
+		if err := progress.Stage(StageCloning); err != nil {
+			return err
+		}
+		<-joined
+		if err := progress.Stage(StageBuilding); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &jobProgress{ctx: ctx}
+	second := &jobProgress{ctx: context.Background()}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- test.manager.deduplicate(ctx, "dep", first, fn)
+	}()
+	time.Sleep(time.Millisecond * 100)
+	waiting := make(chan error, 1)
+	go func() {
+		waiting <- test.manager.deduplicate(context.Background(), "dep", second, fn)
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	cancel()
+	s().ErrorIs(<-errs, context.Canceled)
+	close(joined)
+	s().NoError(<-waiting)
+
+	s().Equal([]Stage{StageCloning}, first.stages)
+	s().Equal([]Stage{StageBuilding}, second.stages)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestLock(t *testing.T) {
+	suite.Run(t, new(TestLockSuite))
+}
@@ -0,0 +1,351 @@
+package dep_manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/ahmetson/os-lib/path"
+	"github.com/go-git/go-git/v5"
+	gitConfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// LockFileName is the default name of the lockfile in the '_sds' directory
+const LockFileName = "deps.lock"
+
+// lockVersion is the format of the lockfile
+const lockVersion = 1
+
+// A LockEntry is the installed version of the dependency
+type LockEntry struct {
+	Url        string    `json:"url"`
+	Branch     string    `json:"branch,omitempty"`
+	Tag        string    `json:"tag,omitempty"`
+	Commit     string    `json:"commit"`      // the resolved commit, empty if the source code is not a git repository
+	BuildFlags []string  `json:"build_flags"` // the flags passed to 'go build'
+	Checksum   string    `json:"checksum"`    // the sha256 of the binary, empty until the locked commit is built
+	Time       time.Time `json:"time"`
+}
+
+// A LockFile is the versions of the installed dependencies by their url
+type LockFile struct {
+	Version int                   `json:"version"`
+	Deps    map[string]*LockEntry `json:"deps"`
+}
+
+// SetLockPath sets the lockfile.
+// Once the lockfile is set, then each Install records the commit, build flags and the binary checksum there.
+// The dependency in the lockfile is installed at the locked commit instead of the branch head.
+//
+// The directory of the lockfile is created if it doesn't exist.
+func (manager *DepManager) SetLockPath(lockPath string) error {
+	dir := filepath.Dir(lockPath)
+	if err := path.MakeDir(dir); err != nil {
+		return fmt.Errorf("path.MakeDir(%s): %w", dir, err)
+	}
+
+	manager.mu.Lock()
+	manager.LockFile = lockPath
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// lockFilePath returns the lockfile set by SetLockPath
+func (manager *DepManager) lockFilePath() string {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.LockFile
+}
+
+// Locked returns the locked version of the dependency by its url.
+// Returns nil if there is no lockfile, or the dependency is not locked.
+func (manager *DepManager) Locked(url string) (*LockEntry, error) {
+	lockPath := manager.lockFilePath()
+	if len(lockPath) == 0 {
+		return nil, nil
+	}
+
+	manager.lockMu.Lock()
+	defer manager.lockMu.Unlock()
+
+	lockFile, err := loadLockFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("loadLockFile('%s'): %w", lockPath, err)
+	}
+
+	return lockFile.Deps[url], nil
+}
+
+// UpdateLock sets the locked commit of the dependency to the head of its branch in the remote repository.
+// If the url is empty, then all dependencies are updated.
+// The dependencies locked to the tag are not updated, as the tag doesn't move.
+//
+// The binary checksum of the updated dependency is cleared.
+// The next Install builds the new commit, and records its checksum.
+//
+// Returns the updated entries.
+func (manager *DepManager) UpdateLock(ctx context.Context, url string) ([]*LockEntry, error) {
+	if manager == nil || ctx == nil {
+		return nil, fmt.Errorf("nil")
+	}
+	lockPath := manager.lockFilePath()
+	if len(lockPath) == 0 {
+		return nil, fmt.Errorf("no lockfile. Call DepManager.SetLockPath first")
+	}
+
+	cloneTimeout, _ := manager.installTimeouts()
+
+	manager.lockMu.Lock()
+	defer manager.lockMu.Unlock()
+
+	lockFile, err := loadLockFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("loadLockFile('%s'): %w", lockPath, err)
+	}
+
+	urls := make([]string, 0, len(lockFile.Deps))
+	if len(url) > 0 {
+		if _, ok := lockFile.Deps[url]; !ok {
+			return nil, fmt.Errorf("the '%s' dep is not locked", url)
+		}
+		urls = append(urls, url)
+	} else {
+		for lockedUrl := range lockFile.Deps {
+			urls = append(urls, lockedUrl)
+		}
+		sort.Strings(urls)
+	}
+
+	updated := make([]*LockEntry, 0, len(urls))
+	for _, lockedUrl := range urls {
+		entry := lockFile.Deps[lockedUrl]
+		if len(entry.Tag) > 0 {
+			continue
+		}
+
+		src, err := source.New(entry.Url)
+		if err != nil {
+			return nil, fmt.Errorf("source.New('%s'): %w", entry.Url, err)
+		}
+		cloneCtx, cancel := withTimeout(ctx, cloneTimeout)
+		commit, err := remoteCommit(cloneCtx, src.GitUrl, entry.Branch)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("remoteCommit('%s', '%s'): %w", src.GitUrl, entry.Branch, err)
+		}
+		if commit == entry.Commit {
+			continue
+		}
+
+		entry.Commit = commit
+		entry.Checksum = ""
+		entry.Time = time.Now()
+		updated = append(updated, entry)
+	}
+
+	if len(updated) > 0 {
+		if err := saveLockFile(lockPath, lockFile); err != nil {
+			return nil, fmt.Errorf("saveLockFile('%s'): %w", lockPath, err)
+		}
+	}
+
+	return updated, nil
+}
+
+// lockedDep returns the dependency pinned to the locked commit.
+// The dependency is returned as is, if it has the tag or commit, or it's locked on another branch.
+// The local source code is never pinned, as it's managed by the developer.
+func (manager *DepManager) lockedDep(dep *Dep) (*Dep, error) {
+	if !dep.manageableSrc || dep.Ref().IsPinned() {
+		return dep, nil
+	}
+
+	entry, err := manager.Locked(dep.Url)
+	if err != nil {
+		return nil, fmt.Errorf("manager.Locked('%s'): %w", dep.Url, err)
+	}
+	if entry == nil || len(entry.Commit) == 0 || entry.Branch != dep.Branch {
+		return dep, nil
+	}
+
+	// the user's dependency is not changed
+	src := *dep.Src
+	src.Commit = entry.Commit
+	pinned := *dep
+	pinned.Src = &src
+
+	return &pinned, nil
+}
+
+// lock records the installed version of the dependency in the lockfile
+func (manager *DepManager) lock(dep *Dep, buildFlags []string) error {
+	lockPath := manager.lockFilePath()
+	if len(lockPath) == 0 {
+		return nil
+	}
+
+	commit, err := srcCommit(dep.srcPath)
+	if err != nil {
+		return fmt.Errorf("srcCommit('%s'): %w", dep.srcPath, err)
+	}
+
+	return manager.lockCommit(dep, commit, buildFlags)
+}
+
+// lockCommit records the installed binary of the dependency as built from the commit
+func (manager *DepManager) lockCommit(dep *Dep, commit string, buildFlags []string) error {
+	lockPath := manager.lockFilePath()
+	if len(lockPath) == 0 {
+		return nil
+	}
+
+	checksum, err := fileChecksum(dep.binPath)
+	if err != nil {
+		return fmt.Errorf("fileChecksum('%s'): %w", dep.binPath, err)
+	}
+
+	manager.lockMu.Lock()
+	defer manager.lockMu.Unlock()
+
+	lockFile, err := loadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("loadLockFile('%s'): %w", lockPath, err)
+	}
+	lockFile.Deps[dep.Url] = &LockEntry{
+		Url:        dep.Url,
+		Branch:     dep.Branch,
+		Tag:        dep.Tag,
+		Commit:     commit,
+		BuildFlags: buildFlags,
+		Checksum:   checksum,
+		Time:       time.Now(),
+	}
+
+	if err := saveLockFile(lockPath, lockFile); err != nil {
+		return fmt.Errorf("saveLockFile('%s'): %w", lockPath, err)
+	}
+
+	return nil
+}
+
+// srcCommit returns the head commit of the source code, or empty string if it's not a git repository
+func srcCommit(srcPath string) (string, error) {
+	repo, err := git.PlainOpen(srcPath)
+	if err != nil {
+		return "", nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("repo.Head: %w", err)
+	}
+
+	return head.Hash().String(), nil
+}
+
+// loadLockFile returns the lockfile. The missing lockfile is empty.
+func loadLockFile(lockPath string) (*LockFile, error) {
+	lockFile := &LockFile{Version: lockVersion, Deps: make(map[string]*LockEntry)}
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lockFile, nil
+		}
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	if err := json.Unmarshal(data, lockFile); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if lockFile.Version != lockVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d, expected %d", lockFile.Version, lockVersion)
+	}
+	if lockFile.Deps == nil {
+		lockFile.Deps = make(map[string]*LockEntry)
+	}
+
+	return lockFile, nil
+}
+
+// saveLockFile writes the lockfile, so it's never partially written
+func saveLockFile(lockPath string, lockFile *LockFile) error {
+	data, err := json.MarshalIndent(lockFile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	tmpPath := lockPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("os.WriteFile('%s'): %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, lockPath); err != nil {
+		return fmt.Errorf("os.Rename('%s', '%s'): %w", tmpPath, lockPath, err)
+	}
+
+	return nil
+}
+
+// fileChecksum returns the hex encoded sha256 of the file
+func fileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("os.Open: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("io.Copy: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// remoteCommit returns the head commit of the branch in the remote repository without cloning it.
+// If the branch is empty, then the commit of the default branch is returned.
+func remoteCommit(ctx context.Context, gitUrl string, branch string) (string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitConfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{gitUrl},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return "", fmt.Errorf("remote.List: %w", ctxErr)
+		}
+		return "", fmt.Errorf("remote.List: %w", err)
+	}
+
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, ref := range refs {
+		byName[ref.Name()] = ref
+	}
+
+	name := plumbing.HEAD
+	if len(branch) > 0 {
+		name = plumbing.NewBranchReferenceName(branch)
+	}
+	ref, ok := byName[name]
+	// the HEAD of the remote is the symbolic reference to the default branch
+	if ok && ref.Type() == plumbing.SymbolicReference {
+		ref, ok = byName[ref.Target()]
+	}
+	if !ok {
+		return "", fmt.Errorf("no '%s' reference", name)
+	}
+
+	return ref.Hash().String(), nil
+}
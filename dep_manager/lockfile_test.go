@@ -0,0 +1,209 @@
+package dep_manager
+
+import (
+	"context"
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestLockFileSuite struct {
+	suite.Suite
+
+	manager *DepManager
+	url     string
+}
+
+func (test *TestLockFileSuite) SetupTest() {
+	s := test.Require
+
+	test.url = "github.com/ahmetson/test-manager"
+	test.manager = New()
+	s().NoError(test.manager.SetLockPath(filepath.Join(test.T().TempDir(), "_sds", LockFileName)))
+}
+
+// Test_10_LoadSave tests reading and writing the lockfile
+func (test *TestLockFileSuite) Test_10_LoadSave() {
+	s := test.Require
+
+	// the missing lockfile is empty
+	lockFile, err := loadLockFile(test.manager.LockFile)
+	s().NoError(err)
+	s().Empty(lockFile.Deps)
+
+	entry, err := test.manager.Locked(test.url)
+	s().NoError(err)
+	s().Nil(entry)
+
+	lockFile.Deps[test.url] = &LockEntry{Url: test.url, Branch: "main", Commit: "a2ac060", BuildFlags: []string{}}
+	s().NoError(saveLockFile(test.manager.LockFile, lockFile))
+
+	entry, err = test.manager.Locked(test.url)
+	s().NoError(err)
+	s().Equal("a2ac060", entry.Commit)
+
+	// the unknown format
+	s().NoError(os.WriteFile(test.manager.LockFile, []byte(`{"version": 100}`), 0644))
+	_, err = test.manager.Locked(test.url)
+	s().Error(err)
+}
+
+// Test_11_Lock tests that the commit and the checksum of the installed dependency are recorded,
+// and the next installation is pinned to the locked commit.
+func (test *TestLockFileSuite) Test_11_Lock() {
+	s := test.Require
+
+	// the source code is the repository with one commit
+	srcPath := test.T().TempDir()
+	repo, err := git.PlainInit(srcPath, false)
+	s().NoError(err)
+	worktree, err := repo.Worktree()
+	s().NoError(err)
+	s().NoError(os.WriteFile(filepath.Join(srcPath, "go.mod"), []byte("module test\n"), 0644))
+	_, err = worktree.Add("go.mod")
+	s().NoError(err)
+	commit, err := worktree.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	s().NoError(err)
+
+	binPath := filepath.Join(test.T().TempDir(), "test-manager")
+	s().NoError(os.WriteFile(binPath, []byte("binary"), 0755))
+
+	dep := &Dep{Src: &source.Src{Url: test.url, Branch: "main"}, srcPath: srcPath, binPath: binPath, manageableSrc: true}
+	s().NoError(test.manager.lock(dep, []string{}))
+
+	entry, err := test.manager.Locked(test.url)
+	s().NoError(err)
+	s().Equal(commit.String(), entry.Commit)
+	s().Equal("main", entry.Branch)
+	// sha256 of 'binary'
+	s().Equal("9a3a45d01531a20e89ac6ae10b0b0beb0492acd7216a368aa062d1a5fecaf9cd", entry.Checksum)
+
+	// the dependency is pinned to the locked commit, the user's dependency is not changed
+	pinned, err := test.manager.lockedDep(dep)
+	s().NoError(err)
+	s().Equal(commit.String(), pinned.Commit)
+	s().Empty(dep.Commit)
+
+	// the dependency on another branch is not pinned
+	dep.Branch = "dev"
+	pinned, err = test.manager.lockedDep(dep)
+	s().NoError(err)
+	s().Empty(pinned.Commit)
+
+	// the dependency with the tag is pinned already
+	dep.Branch = ""
+	dep.Tag = "v1.0.0"
+	pinned, err = test.manager.lockedDep(dep)
+	s().NoError(err)
+	s().Equal(dep, pinned)
+
+	// the local source code is not pinned
+	dep.Tag = ""
+	dep.Branch = "main"
+	dep.manageableSrc = false
+	pinned, err = test.manager.lockedDep(dep)
+	s().NoError(err)
+	s().Equal(dep, pinned)
+}
+
+// Test_12_UpdateLock tests the update of the locked commits
+func (test *TestLockFileSuite) Test_12_UpdateLock() {
+	s := test.Require
+
+	_, err := New().UpdateLock(context.Background(), "")
+	s().Error(err)
+
+	_, err = test.manager.UpdateLock(context.Background(), test.url)
+	s().Error(err)
+
+	// the tag is not updated, so the remote repository is not requested
+	lockFile, err := loadLockFile(test.manager.LockFile)
+	s().NoError(err)
+	lockFile.Deps[test.url] = &LockEntry{Url: test.url, Tag: "v1.0.0", Commit: "a2ac060", BuildFlags: []string{}}
+	s().NoError(saveLockFile(test.manager.LockFile, lockFile))
+
+	updated, err := test.manager.UpdateLock(context.Background(), "")
+	s().NoError(err)
+	s().Empty(updated)
+}
+
+// Test_13_RemoteCommit tests resolving the branch head without cloning
+func (test *TestLockFileSuite) Test_13_RemoteCommit() {
+	s := test.Require
+
+	gitPath := test.T().TempDir()
+	repo, err := git.PlainInit(gitPath, false)
+	s().NoError(err)
+	worktree, err := repo.Worktree()
+	s().NoError(err)
+	s().NoError(os.WriteFile(filepath.Join(gitPath, "go.mod"), []byte("module test\n"), 0644))
+	_, err = worktree.Add("go.mod")
+	s().NoError(err)
+	commit, err := worktree.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	s().NoError(err)
+
+	head, err := remoteCommit(context.Background(), gitPath, "master")
+	s().NoError(err)
+	s().Equal(commit.String(), head)
+
+	// the default branch
+	head, err = remoteCommit(context.Background(), gitPath, "")
+	s().NoError(err)
+	s().Equal(commit.String(), head)
+
+	_, err = remoteCommit(context.Background(), gitPath, "no-branch")
+	s().Error(err)
+}
+
+// Test_14_SetPathsConcurrently tests that the paths are set while the DepManager reads them
+func (test *TestLockFileSuite) Test_14_SetPathsConcurrently() {
+	s := test.Require
+
+	lockPath := test.manager.LockFile
+	logPath := test.T().TempDir()
+	statePath := test.T().TempDir()
+	setters := []func(){
+		func() { test.manager.SetInstallTimeouts(time.Minute, time.Minute) },
+		func() { _ = test.manager.SetLockPath(lockPath) },
+		func() { _ = test.manager.SetLogPath(logPath) },
+		func() { _ = test.manager.SetStatePath(statePath) },
+	}
+	var wg sync.WaitGroup
+	for _, set := range setters {
+		wg.Add(1)
+		go func(set func()) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				set()
+			}
+		}(set)
+	}
+
+	for i := 0; i < 100; i++ {
+		_, err := test.manager.Locked(test.url)
+		s().NoError(err)
+		_, _ = test.manager.Logs("dep", 1)
+		_, _ = test.manager.Reconcile()
+		test.manager.installTimeouts()
+	}
+	wg.Wait()
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestLockFile(t *testing.T) {
+	suite.Run(t, new(TestLockFileSuite))
+}
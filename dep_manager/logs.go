@@ -0,0 +1,271 @@
+package dep_manager
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ahmetson/os-lib/path"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultLogMaxSize is the size of the log file in bytes, after which it's rotated
+	DefaultLogMaxSize int64 = 10 * 1024 * 1024
+	// DefaultLogMaxFiles is the amount of the rotated log files kept along with the current one
+	DefaultLogMaxFiles = 3
+)
+
+// SetLogPath sets the directory where the output of the spawned dependencies is written.
+// Each dependency has its own '<id>.log' file.
+// The directory is created if it doesn't exist.
+//
+// If the log path is not set, then the output is written to the terminal.
+func (manager *DepManager) SetLogPath(logPath string) error {
+	if err := path.MakeDir(logPath); err != nil {
+		return fmt.Errorf("path.MakeDir(%s): %w", logPath, err)
+	}
+
+	manager.mu.Lock()
+	manager.LogDir = logPath
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// logDir returns the directory of the dependency output
+func (manager *DepManager) logDir() string {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.LogDir
+}
+
+// SetLogRotation sets the size in bytes after which the log file is rotated,
+// and the amount of the rotated files to keep. The older files are deleted.
+func (manager *DepManager) SetLogRotation(maxSize int64, maxFiles int) error {
+	if maxSize <= 0 {
+		return fmt.Errorf("max size must be positive")
+	}
+	if maxFiles < 0 {
+		return fmt.Errorf("max files can't be negative")
+	}
+
+	manager.mu.Lock()
+	manager.logMaxSize = maxSize
+	manager.logMaxFiles = maxFiles
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// Logs returns the last lines of the dependency output.
+// The log is kept after the dependency exits, so the crashed dependency could be inspected.
+// If the current log file has fewer lines, then the rest is read from the rotated files.
+func (manager *DepManager) Logs(id string, lines int) ([]string, error) {
+	if len(manager.logDir()) == 0 {
+		return nil, fmt.Errorf("no log path. Call DepManager.SetLogPath first")
+	}
+	if lines <= 0 {
+		return []string{}, nil
+	}
+
+	logPath := manager.logPath(id)
+	if _, err := os.Stat(logPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, newError(ErrNotFound, fmt.Errorf("os.Stat('%s'): %w", logPath, err))
+		}
+		return nil, fmt.Errorf("os.Stat('%s'): %w", logPath, err)
+	}
+
+	manager.mu.RLock()
+	maxFiles := manager.logMaxFiles
+	manager.mu.RUnlock()
+
+	tail := make([]string, 0, lines)
+	for i := 0; i <= maxFiles && len(tail) < lines; i++ {
+		data, err := os.ReadFile(rotatedPath(logPath, i))
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile('%s'): %w", rotatedPath(logPath, i), err)
+		}
+
+		fileLines := splitLines(data)
+		if missing := lines - len(tail); len(fileLines) > missing {
+			fileLines = fileLines[len(fileLines)-missing:]
+		}
+		tail = append(fileLines, tail...)
+	}
+
+	return tail, nil
+}
+
+// LogsFrom returns the complete lines written to the log of the dependency after the offset,
+// and the offset to read the next lines from. Used to follow the output of the dependency.
+//
+// If the log was rotated since the offset was returned, then the lines are read from the beginning of the new log.
+func (manager *DepManager) LogsFrom(id string, offset int64) ([]string, int64, error) {
+	if len(manager.logDir()) == 0 {
+		return nil, 0, fmt.Errorf("no log path. Call DepManager.SetLogPath first")
+	}
+
+	logPath := manager.logPath(id)
+	file, err := os.Open(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, newError(ErrNotFound, fmt.Errorf("os.Open('%s'): %w", logPath, err))
+		}
+		return nil, 0, fmt.Errorf("os.Open('%s'): %w", logPath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("file.Stat: %w", err)
+	}
+	if offset < 0 || offset > stat.Size() {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, fmt.Errorf("file.Seek(%d): %w", offset, err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("io.ReadAll: %w", err)
+	}
+	// the last line is not written completely
+	end := bytes.LastIndexByte(data, '\n') + 1
+
+	return splitLines(data[:end]), offset + int64(end), nil
+}
+
+// logPath returns the log file of the dependency by its id
+func (manager *DepManager) logPath(id string) string {
+	return filepath.Join(manager.logDir(), urlToFileName(id)+".log")
+}
+
+// rotatedPath returns the path of the rotated log file. The current log file has 0 index.
+func rotatedPath(logPath string, index int) string {
+	if index == 0 {
+		return logPath
+	}
+	return fmt.Sprintf("%s.%d", logPath, index)
+}
+
+// splitLines returns the lines without the line breaks
+func splitLines(data []byte) []string {
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(text) == 0 {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// rotateLog shifts the log files, so the current log file becomes the first rotated file.
+// The files beyond the max files are deleted.
+func rotateLog(logPath string, maxFiles int) error {
+	if maxFiles == 0 {
+		if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("os.Remove('%s'): %w", logPath, err)
+		}
+		return nil
+	}
+
+	oldest := rotatedPath(logPath, maxFiles)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Remove('%s'): %w", oldest, err)
+	}
+	for i := maxFiles - 1; i >= 0; i-- {
+		from := rotatedPath(logPath, i)
+		to := rotatedPath(logPath, i+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("os.Rename('%s', '%s'): %w", from, to, err)
+		}
+	}
+
+	return nil
+}
+
+// The rotatingLog writes the output of the spawned dependency into its log file.
+// The file is rotated when it exceeds the max size.
+type rotatingLog struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// openLog opens the log file for appending
+func openLog(logPath string, maxSize int64, maxFiles int) (*rotatingLog, error) {
+	l := &rotatingLog{path: logPath, maxSize: maxSize, maxFiles: maxFiles}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *rotatingLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile('%s'): %w", l.path, err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("file.Stat: %w", err)
+	}
+
+	l.file = file
+	l.size = stat.Size()
+	return nil
+}
+
+// Write the output. If the output doesn't fit into the file, then the file is rotated first.
+func (l *rotatingLog) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, fmt.Errorf("closed")
+	}
+
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.file.Close(); err != nil {
+			return 0, fmt.Errorf("file.Close: %w", err)
+		}
+		l.file = nil
+		if err := rotateLog(l.path, l.maxFiles); err != nil {
+			return 0, fmt.Errorf("rotateLog: %w", err)
+		}
+		if err := l.open(); err != nil {
+			return 0, fmt.Errorf("l.open: %w", err)
+		}
+	}
+
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return n, err
+}
+
+// Close the log file
+func (l *rotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
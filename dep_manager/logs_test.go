@@ -0,0 +1,104 @@
+package dep_manager
+
+import (
+	"github.com/stretchr/testify/suite"
+	"os"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestLogsSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestLogsSuite) SetupTest() {
+	s := test.Require
+
+	test.manager = New()
+	s().NoError(test.manager.SetLogPath(test.T().TempDir()))
+}
+
+// Test_10_Rotate tests that the log is rotated by the size, and the old files are deleted
+func (test *TestLogsSuite) Test_10_Rotate() {
+	s := test.Require
+
+	s().Error(test.manager.SetLogRotation(0, 1))
+	s().NoError(test.manager.SetLogRotation(10, 2))
+
+	logPath := test.manager.logPath("dep")
+	depLog, err := openLog(logPath, 10, 2)
+	s().NoError(err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = depLog.Write([]byte(line))
+		s().NoError(err)
+	}
+	s().NoError(depLog.Close())
+
+	// each line exceeds the half of the max size, so each file has one line
+	data, err := os.ReadFile(logPath)
+	s().NoError(err)
+	s().Equal("fourth\n", string(data))
+	data, err = os.ReadFile(rotatedPath(logPath, 2))
+	s().NoError(err)
+	s().Equal("second\n", string(data))
+	_, err = os.Stat(rotatedPath(logPath, 3))
+	s().True(os.IsNotExist(err))
+
+	// the last lines are read from the rotated files
+	lines, err := test.manager.Logs("dep", 2)
+	s().NoError(err)
+	s().Equal([]string{"third", "fourth"}, lines)
+
+	lines, err = test.manager.Logs("dep", 10)
+	s().NoError(err)
+	s().Equal([]string{"second", "third", "fourth"}, lines)
+
+	_, err = test.manager.Logs("no-dep", 10)
+	s().Error(err)
+}
+
+// Test_11_LogsFrom tests following the log by the offset
+func (test *TestLogsSuite) Test_11_LogsFrom() {
+	s := test.Require
+
+	depLog, err := openLog(test.manager.logPath("dep"), DefaultLogMaxSize, DefaultLogMaxFiles)
+	s().NoError(err)
+	defer func() {
+		_ = depLog.Close()
+	}()
+
+	_, err = depLog.Write([]byte("first\nsec"))
+	s().NoError(err)
+
+	lines, offset, err := test.manager.LogsFrom("dep", 0)
+	s().NoError(err)
+	s().Equal([]string{"first"}, lines)
+	s().Equal(int64(6), offset)
+
+	// the incomplete line is returned when it's finished
+	_, err = depLog.Write([]byte("ond\n"))
+	s().NoError(err)
+	lines, offset, err = test.manager.LogsFrom("dep", offset)
+	s().NoError(err)
+	s().Equal([]string{"second"}, lines)
+
+	lines, _, err = test.manager.LogsFrom("dep", offset)
+	s().NoError(err)
+	s().Empty(lines)
+
+	// the offset of the rotated log is out of the file
+	lines, _, err = test.manager.LogsFrom("dep", offset*10)
+	s().NoError(err)
+	s().Equal([]string{"first", "second"}, lines)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestLogs(t *testing.T) {
+	suite.Run(t, new(TestLogsSuite))
+}
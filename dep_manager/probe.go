@@ -0,0 +1,132 @@
+package dep_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ahmetson/client-lib"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/datatype-lib/data_type/key_value"
+	"github.com/ahmetson/datatype-lib/message"
+	"net"
+	"time"
+)
+
+// ProbeFailure is the reason why the dependency didn't reply to the heartbeat
+type ProbeFailure = string
+
+const (
+	ProbeRefused  ProbeFailure = "refused"   // nothing listens on the port
+	ProbeTimeout  ProbeFailure = "timeout"   // no reply within the timeout
+	ProbeBadReply ProbeFailure = "bad-reply" // the reply is not valid, or the heartbeat failed
+)
+
+// A ProbeResult is the outcome of the heartbeat sent to the dependency
+type ProbeResult struct {
+	Id        string        `json:"id"`
+	Url       string        `json:"url"`
+	Reachable bool          `json:"reachable"`         // the dependency replied, even if the reply is bad
+	Latency   time.Duration `json:"latency"`           // the round trip of the heartbeat
+	Failure   ProbeFailure  `json:"failure,omitempty"` // empty if the dependency replied successfully
+	Error     string        `json:"error,omitempty"`
+
+	// The metadata that the dependency included into the heartbeat reply, optional
+	Version  string             `json:"version,omitempty"`
+	DepId    string             `json:"dep_id,omitempty"`
+	Parent   string             `json:"parent,omitempty"`
+	Uptime   time.Duration      `json:"uptime,omitempty"`
+	Metadata key_value.KeyValue `json:"metadata,omitempty"` // all reply parameters
+}
+
+// OK returns true if the dependency replied successfully
+func (result *ProbeResult) OK() bool {
+	return result != nil && result.Reachable && len(result.Failure) == 0
+}
+
+// Probe sends the heartbeat to the dependency.
+// Unlike Running, it returns why the dependency is not reachable, and what the dependency replied.
+//
+// The error is returned only if the socket parameters are invalid.
+func (manager *DepManager) Probe(c *clientConfig.Client) (*ProbeResult, error) {
+	if manager == nil || c == nil {
+		return nil, fmt.Errorf("nil")
+	}
+
+	// the parameters may be shared by the concurrent probes
+	depClient := *c
+	depClient.UrlFunc(clientConfig.Url)
+
+	result := &ProbeResult{Id: depClient.Id, Url: depClient.Url()}
+
+	// zeromq reconnects silently, so the closed port is detected by the plain connection
+	if depClient.Port > 0 {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", depClient.Port), manager.timeout)
+		if err != nil {
+			result.Failure = dialFailure(err)
+			result.Error = err.Error()
+			return result, nil
+		}
+		_ = conn.Close()
+	}
+
+	sock, err := client.New(&depClient)
+	if err != nil {
+		return nil, fmt.Errorf("client.New: %w", err)
+	}
+	defer func() {
+		_ = sock.Close()
+	}()
+	sock.Attempt(1).Timeout(manager.timeout)
+
+	req := &message.Request{
+		Command:    "heartbeat",
+		Parameters: key_value.New(),
+	}
+
+	start := time.Now()
+	reply, err := sock.Request(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Failure = requestFailure(err)
+		result.Error = err.Error()
+		result.Reachable = result.Failure == ProbeBadReply
+		return result, nil
+	}
+	result.Reachable = true
+
+	if !reply.IsOK() {
+		result.Failure = ProbeBadReply
+		result.Error = reply.ErrorMessage()
+		return result, nil
+	}
+
+	params := reply.ReplyParameters()
+	result.Metadata = params
+	result.Version, _ = params.StringValue("version")
+	result.DepId, _ = params.StringValue("id")
+	result.Parent, _ = params.StringValue("parent")
+	if uptime, err := params.Uint64Value("uptime"); err == nil {
+		result.Uptime = time.Duration(uptime) * time.Second
+	}
+
+	return result, nil
+}
+
+// dialFailure returns the reason of the failed connection
+func dialFailure(err error) ProbeFailure {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ProbeTimeout
+	}
+	return ProbeRefused
+}
+
+// requestFailure returns the reason of the failed heartbeat
+func requestFailure(err error) ProbeFailure {
+	if socketInvalidReply(err) {
+		return ProbeBadReply
+	}
+	if SocketTimeout(err) {
+		return ProbeTimeout
+	}
+	return ProbeRefused
+}
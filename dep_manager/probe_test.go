@@ -0,0 +1,71 @@
+package dep_manager
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	zmq "github.com/pebbe/zmq4"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestProbeSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestProbeSuite) SetupTest() {
+	test.manager = New()
+}
+
+// Test_10_Failure tests the classification of the failed heartbeats
+func (test *TestProbeSuite) Test_10_Failure() {
+	s := test.Require
+
+	// the errors as the client-lib socket returns them
+	s().Equal(ProbeTimeout, requestFailure(fmt.Errorf("socket.RawRequest: %w", fmt.Errorf("timeout"))))
+	s().Equal(ProbeBadReply, requestFailure(fmt.Errorf("failed to parse the command 'heartbeat': %w", fmt.Errorf("invalid"))))
+	s().Equal(ProbeRefused, requestFailure(fmt.Errorf("socket.RawRequest: %w", fmt.Errorf("socket.rawSubmit: %w", fmt.Errorf("connection refused")))))
+
+	var ok *ProbeResult
+	s().False(ok.OK())
+	s().False((&ProbeResult{Reachable: true, Failure: ProbeBadReply}).OK())
+	s().True((&ProbeResult{Reachable: true}).OK())
+}
+
+// Test_11_Refused tests that nothing listening on the port is refused
+func (test *TestProbeSuite) Test_11_Refused() {
+	s := test.Require
+
+	_, err := test.manager.Probe(nil)
+	s().Error(err)
+
+	// the port is free after the listener is closed
+	listener, err := net.Listen("tcp", "localhost:0")
+	s().NoError(err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	s().NoError(listener.Close())
+
+	c := clientConfig.New("", "dep", uint64(port), zmq.REP)
+	result, err := test.manager.Probe(c)
+	s().NoError(err)
+	s().False(result.Reachable)
+	s().False(result.OK())
+	s().Equal(ProbeRefused, result.Failure)
+	s().NotEmpty(result.Error)
+	s().Equal("dep", result.Id)
+	s().Equal(fmt.Sprintf("tcp://localhost:%d", port), result.Url)
+
+	// the parameters of the caller are not changed
+	s().Empty(c.Url())
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestProbe(t *testing.T) {
+	suite.Run(t, new(TestProbeSuite))
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+// +build !windows
+
+package dep_manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// detachAttr starts the process in its own session.
+// Therefore, the process is not killed along with the parent's terminal or process group.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive checks whether the process with the given pid exists.
+// The process may belong to another user, in that case it's still considered as alive.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// groupAttr starts the process in its own process group.
+// Therefore, the children of the process are signaled along with it.
+func groupAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess sends SIGTERM to the process group led by the pid.
+// The process that exited already is not an error.
+func terminateProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// killProcessGroup sends SIGKILL to the process group led by the pid.
+// The process that exited already is not an error.
+func killProcessGroup(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// signalGroup sends the signal to the process group.
+// If the process is not the group leader, then the signal is sent to the process only.
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	if err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("syscall.Kill(%d, %s): %w", pid, sig, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package dep_manager
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	detachedProcess = 0x00000008 // DETACHED_PROCESS creation flag, not defined in syscall
+	stillActive     = 259        // STILL_ACTIVE exit code of the running process
+)
+
+// detachAttr starts the process in its own process group without the parent's console.
+// Therefore, the process is not killed along with the parent's console.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// processAlive checks whether the process with the given pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = syscall.CloseHandle(handle)
+	}()
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+
+	return code == stillActive
+}
+
+// groupAttr starts the process in its own process group.
+func groupAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminateProcess asks the process tree to close.
+// Windows has no termination signal, so the close message is sent by taskkill.
+func terminateProcess(pid int) error {
+	if !processAlive(pid) {
+		return nil
+	}
+	// the process without the window may ignore the close message, then it's killed
+	_ = exec.Command("taskkill", "/PID", strconv.Itoa(pid), "/T").Run()
+	return nil
+}
+
+// killProcessGroup kills the process tree.
+func killProcessGroup(pid int) error {
+	if !processAlive(pid) {
+		return nil
+	}
+	if err := exec.Command("taskkill", "/PID", strconv.Itoa(pid), "/T", "/F").Run(); err != nil {
+		return fmt.Errorf("taskkill /PID %d /T /F: %w", pid, err)
+	}
+	return nil
+}
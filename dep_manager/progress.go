@@ -0,0 +1,120 @@
+package dep_manager
+
+import (
+	"io"
+	"sync"
+)
+
+// Stage is the step of the installation
+type Stage = string
+
+const (
+	StageCloning  Stage = "cloning"  // the source code is downloading
+	StageTidying  Stage = "tidying"  // the modules of the source code are updating
+	StageBuilding Stage = "building" // the binary is building
+)
+
+// Progress receives the stages and the output of the installation.
+type Progress interface {
+	io.Writer
+	// Stage is called before the stage starts.
+	// If it returns an error, then the update stops with that error.
+	// The installation shared by the concurrent callers stops notifying the progress instead.
+	Stage(stage Stage) error
+}
+
+// SetProgress sets the receiver of the installation progress.
+// The concurrent installation of the same dependency is built once,
+// and its progress is passed to the progress of each waiting caller.
+func (dep *Dep) SetProgress(progress Progress) {
+	if dep == nil {
+		return
+	}
+	dep.progress = progress
+}
+
+// stage notifies the progress about the stage, if the progress is set
+func (dep *Dep) stage(stage Stage) error {
+	if dep.progress == nil {
+		return nil
+	}
+	return dep.progress.Stage(stage)
+}
+
+// withProgress returns the writer that writes to the progress as well
+func withProgress(w io.Writer, progress io.Writer) io.Writer {
+	if progress == nil {
+		return w
+	}
+	return io.MultiWriter(w, progress)
+}
+
+// The sharedProgress passes the progress of the installation shared by the concurrent callers
+// to the progress of each waiting caller.
+// The progress that returns an error is detached, so it doesn't stop the installation of the others.
+// The shared installation is stopped by the contexts of the callers instead, see DepManager.deduplicate.
+type sharedProgress struct {
+	mu        sync.Mutex
+	next      int
+	receivers map[int]Progress
+}
+
+func newSharedProgress() *sharedProgress {
+	return &sharedProgress{receivers: make(map[int]Progress)}
+}
+
+// add the progress of the caller. Returns the id to remove it, or -1 if the progress is nil.
+func (shared *sharedProgress) add(progress Progress) int {
+	if progress == nil {
+		return -1
+	}
+
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	shared.next++
+	shared.receivers[shared.next] = progress
+	return shared.next
+}
+
+// remove the progress of the caller that stopped waiting
+func (shared *sharedProgress) remove(id int) {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	delete(shared.receivers, id)
+}
+
+// list returns the current receivers, so they are called without the lock
+func (shared *sharedProgress) list() map[int]Progress {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	receivers := make(map[int]Progress, len(shared.receivers))
+	for id, progress := range shared.receivers {
+		receivers[id] = progress
+	}
+	return receivers
+}
+
+// Stage notifies all receivers. The receiver that fails is detached.
+func (shared *sharedProgress) Stage(stage Stage) error {
+	for id, progress := range shared.list() {
+		if err := progress.Stage(stage); err != nil {
+			shared.remove(id)
+		}
+	}
+
+	return nil
+}
+
+// Write passes the output to all receivers. The receiver that fails is detached.
+func (shared *sharedProgress) Write(data []byte) (int, error) {
+	for id, progress := range shared.list() {
+		if _, err := progress.Write(data); err != nil {
+			shared.remove(id)
+		}
+	}
+
+	return len(data), nil
+}
@@ -0,0 +1,94 @@
+package dep_manager
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"time"
+)
+
+// readyInterval is the interval between the heartbeats while waiting for the dependency to be ready
+const readyInterval = time.Millisecond * 100
+
+// SetReadyTimeout makes DepManager.Run to wait until the dependency replies to the heartbeat.
+// The dependency socket must be set by Dep.SetManager.
+// Pass 0 to return right after the dependency is spawned.
+func (dep *Dep) SetReadyTimeout(timeout time.Duration) {
+	if dep == nil {
+		return
+	}
+	dep.readyTimeout = timeout
+}
+
+// WaitReady waits until the running dependency replies to the heartbeat.
+// If the socket parameters are nil, then the socket set by Dep.SetManager is used.
+//
+// Returns ErrExited if the dependency exits before being ready,
+// and ErrTimeout if it's not ready within the timeout. The dependency that is not ready is not stopped.
+//
+// The result is emitted as EventReady or EventNotReady. See DepManager.OnEvent.
+func (manager *DepManager) WaitReady(id string, c *clientConfig.Client, timeout time.Duration) error {
+	if manager == nil || len(id) == 0 {
+		return fmt.Errorf("nil or no id")
+	}
+
+	manager.mu.RLock()
+	instance, ok := manager.runningDeps[id]
+	var exited chan struct{}
+	if ok {
+		exited = instance.exited
+		if c == nil {
+			c = instance.manager
+		}
+	}
+	manager.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("the '%s' dep is not running", id)
+	}
+	if c == nil {
+		return fmt.Errorf("no socket parameters of the '%s' dep. Call Dep.SetManager first", id)
+	}
+
+	return manager.ready(id, c, exited, timeout)
+}
+
+// The ready waits for the dependency, and emits the result.
+// The exited channel is closed when the dependency exits.
+func (manager *DepManager) ready(id string, c *clientConfig.Client, exited chan struct{}, timeout time.Duration) error {
+	err := manager.waitReady(c, exited, timeout)
+	if err != nil {
+		manager.emit(&Event{Id: id, Type: EventNotReady, Err: err})
+		return fmt.Errorf("the '%s' dep: %w", id, err)
+	}
+	manager.emit(&Event{Id: id, Type: EventReady})
+
+	return nil
+}
+
+// waitReady polls the dependency until it replies to the heartbeat, exits or the timeout passes
+func (manager *DepManager) waitReady(c *clientConfig.Client, exited chan struct{}, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		select {
+		case <-exited:
+			return newError(ErrExited, fmt.Errorf("exited before being ready"))
+		default:
+		}
+
+		// the probe closes its socket, and doesn't change the parameters shared with the health monitor
+		result, err := manager.Probe(c)
+		if err != nil {
+			return fmt.Errorf("manager.Probe: %w", err)
+		}
+		if result.Reachable {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return newError(ErrTimeout, fmt.Errorf("not ready after %s", timeout))
+		}
+		select {
+		case <-exited:
+		case <-time.After(readyInterval):
+		}
+	}
+}
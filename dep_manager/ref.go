@@ -0,0 +1,66 @@
+package dep_manager
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"strings"
+)
+
+// checkoutRef checks out the commit of the dependency in the cloned repository.
+// The tag and branch are checked out by the clone itself.
+func checkoutRef(repo *git.Repository, dep *Dep) error {
+	if len(dep.Commit) == 0 {
+		return nil
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(dep.Commit))
+	if err != nil {
+		return fmt.Errorf("repo.ResolveRevision('%s'): %w", dep.Commit, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("repo.Worktree: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash}); err != nil {
+		return fmt.Errorf("worktree.Checkout('%s'): %w", hash, err)
+	}
+
+	return nil
+}
+
+// verifyRef returns ErrRefMismatch if the source code is not at the tag or commit of the dependency.
+// The dependency without the tag or commit is not verified, as the branch head moves.
+func verifyRef(dep *Dep) error {
+	if !dep.Ref().IsPinned() {
+		return nil
+	}
+
+	repo, err := git.PlainOpen(dep.srcPath)
+	if err != nil {
+		return fmt.Errorf("git.PlainOpen('%s'): %w", dep.srcPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("repo.Head: %w", err)
+	}
+	headHash := head.Hash().String()
+
+	if len(dep.Commit) > 0 && !strings.HasPrefix(headHash, strings.ToLower(dep.Commit)) {
+		return newError(ErrRefMismatch, fmt.Errorf("the source code is at '%s' commit, expected '%s'", headHash, dep.Commit))
+	}
+
+	if len(dep.Tag) > 0 {
+		// the annotated tag is resolved to its commit
+		tagHash, err := repo.ResolveRevision(plumbing.Revision(plumbing.NewTagReferenceName(dep.Tag)))
+		if err != nil {
+			return newError(ErrRefMismatch, fmt.Errorf("repo.ResolveRevision('%s'): %w", dep.Tag, err))
+		}
+		if tagHash.String() != headHash {
+			return newError(ErrRefMismatch, fmt.Errorf("the source code is at '%s' commit, the '%s' tag is at '%s'", headHash, dep.Tag, tagHash))
+		}
+	}
+
+	return nil
+}
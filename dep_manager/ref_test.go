@@ -0,0 +1,110 @@
+package dep_manager
+
+import (
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestRefSuite struct {
+	suite.Suite
+
+	srcPath string
+	repo    *git.Repository
+	commits []plumbing.Hash
+}
+
+// SetupTest creates the repository with two commits, the first one is tagged as v1.0.0
+func (test *TestRefSuite) SetupTest() {
+	s := test.Require
+
+	test.srcPath = test.T().TempDir()
+	repo, err := git.PlainInit(test.srcPath, false)
+	s().NoError(err)
+	test.repo = repo
+
+	worktree, err := repo.Worktree()
+	s().NoError(err)
+
+	test.commits = make([]plumbing.Hash, 0, 2)
+	for _, content := range []string{"module first\n", "module second\n"} {
+		s().NoError(os.WriteFile(filepath.Join(test.srcPath, "go.mod"), []byte(content), 0644))
+		_, err = worktree.Add("go.mod")
+		s().NoError(err)
+		hash, err := worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		s().NoError(err)
+		test.commits = append(test.commits, hash)
+	}
+
+	_, err = repo.CreateTag("v1.0.0", test.commits[0], &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "v1.0.0",
+	})
+	s().NoError(err)
+}
+
+// Test_10_Verify tests that the source code at another version is not built
+func (test *TestRefSuite) Test_10_Verify() {
+	s := test.Require
+
+	dep := &Dep{Src: &source.Src{}, srcPath: test.srcPath}
+
+	// the branch head is not verified
+	s().NoError(verifyRef(dep))
+
+	// the head is at the second commit
+	dep.Commit = test.commits[1].String()[:7]
+	s().NoError(verifyRef(dep))
+
+	dep.Commit = test.commits[0].String()
+	s().ErrorIs(verifyRef(dep), ErrRefMismatch)
+
+	// the annotated tag is at the first commit
+	dep.Commit = ""
+	dep.Tag = "v1.0.0"
+	s().ErrorIs(verifyRef(dep), ErrRefMismatch)
+
+	dep.Tag = "v2.0.0"
+	s().ErrorIs(verifyRef(dep), ErrRefMismatch)
+}
+
+// Test_11_Checkout tests that the commit is checked out by its prefix
+func (test *TestRefSuite) Test_11_Checkout() {
+	s := test.Require
+
+	dep := &Dep{Src: &source.Src{}, srcPath: test.srcPath}
+	s().NoError(checkoutRef(test.repo, dep))
+
+	dep.Commit = "ffffffff"
+	s().Error(checkoutRef(test.repo, dep))
+
+	dep.Commit = test.commits[0].String()[:8]
+	s().NoError(checkoutRef(test.repo, dep))
+	s().NoError(verifyRef(dep))
+
+	data, err := os.ReadFile(filepath.Join(test.srcPath, "go.mod"))
+	s().NoError(err)
+	s().Equal("module first\n", string(data))
+
+	// the tag is at the checked out commit
+	dep.Commit = ""
+	dep.Tag = "v1.0.0"
+	s().NoError(verifyRef(dep))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestRef(t *testing.T) {
+	suite.Run(t, new(TestRefSuite))
+}
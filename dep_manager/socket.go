@@ -0,0 +1,40 @@
+package dep_manager
+
+import (
+	"errors"
+	"strings"
+)
+
+// The client-lib socket returns the plain errors without the types.
+// These are its messages, so they are matched in one place.
+const (
+	socketTimeout       = "timeout"                      // socket.RawRequest got no reply after all attempts
+	socketSubmitTimeout = "submit timeout"               // socket.RawSubmit couldn't send after all attempts
+	socketBadReply      = "failed to parse the command " // socket.Request got the reply that is not a message
+)
+
+// SocketTimeout returns true if the client-lib socket request failed because no reply came in time.
+// Only the timeout of the socket itself is matched, not any error with the 'timeout' in its message.
+func SocketTimeout(err error) bool {
+	for err != nil {
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			return err.Error() == socketTimeout || err.Error() == socketSubmitTimeout
+		}
+		err = cause
+	}
+
+	return false
+}
+
+// socketInvalidReply returns true if the client-lib socket received the reply, but it's not a valid message
+func socketInvalidReply(err error) bool {
+	for err != nil {
+		if strings.HasPrefix(err.Error(), socketBadReply) {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package dep_manager
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestSocketSuite struct {
+	suite.Suite
+}
+
+// Test_10_SocketTimeout tests the timeout errors as the client-lib socket returns them
+func (test *TestSocketSuite) Test_10_SocketTimeout() {
+	s := test.Require
+
+	// socket.Request wraps the errors of socket.RawRequest
+	timeout := fmt.Errorf("socket.RawRequest: %w", fmt.Errorf("timeout"))
+	s().True(SocketTimeout(timeout))
+	s().True(SocketTimeout(fmt.Errorf("socket.Request('install-dep'): %w", timeout)))
+	s().True(SocketTimeout(fmt.Errorf("socket.RawSubmit: %w", fmt.Errorf("submit timeout"))))
+
+	s().False(SocketTimeout(nil))
+	s().False(SocketTimeout(fmt.Errorf("socket.RawRequest: %w", fmt.Errorf("poll error: %w", fmt.Errorf("dial tcp: i/o timeout")))))
+	s().False(SocketTimeout(fmt.Errorf("socket.Request: timeout")))
+
+	invalid := fmt.Errorf("failed to parse the command 'heartbeat': %w", fmt.Errorf("invalid"))
+	s().True(socketInvalidReply(fmt.Errorf("socket.Request('heartbeat'): %w", invalid)))
+	s().False(socketInvalidReply(timeout))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestSocket(t *testing.T) {
+	suite.Run(t, new(TestSocketSuite))
+}
@@ -0,0 +1,226 @@
+package dep_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/dev-lib/source"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// recordExt is the extension of the state files
+const recordExt = ".json"
+
+// A Record is the state of the spawned dependency persisted in the DepManager.State directory.
+// The record lets another DepManager to re-attach to the dependency or to clean it up.
+type Record struct {
+	Id        string               `json:"id"`
+	Url       string               `json:"url"`
+	Pid       int                  `json:"pid"`
+	BinPath   string               `json:"bin_path"`
+	Args      []string             `json:"args"`
+	Parent    *clientConfig.Client `json:"parent,omitempty"`
+	Manager   *clientConfig.Client `json:"manager,omitempty"` // the socket of the dependency, if it's known
+	Detached  bool                 `json:"detached"`
+	StartTime time.Time            `json:"start_time"`
+}
+
+// A Reconciliation is the report of DepManager.Reconcile.
+// Each field is the list of the dependency ids.
+type Reconciliation struct {
+	Adopted []string // still running dependencies tracked by the DepManager
+	Killed  []string // stale processes that were killed
+	Removed []string // records of the exited dependencies
+}
+
+// IsEmpty returns true if nothing was recorded.
+func (report *Reconciliation) IsEmpty() bool {
+	return len(report.Adopted) == 0 && len(report.Killed) == 0 && len(report.Removed) == 0
+}
+
+// Reconcile the records left by the previous DepManager, for example, after the crash of the primary service.
+//
+//   - The record of the exited dependency is removed.
+//   - The dependency that answers the heartbeat is adopted.
+//   - The detached dependency without the known socket is adopted as long as its process is alive.
+//   - Any other process is stale, it's killed and its record is removed.
+//
+// The adopted dependencies are not the children of this process.
+// Therefore, DepManager.OnStop of the adopted dependency returns nil error, as the exit status is unknown.
+func (manager *DepManager) Reconcile() (*Reconciliation, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("nil")
+	}
+	statePath := manager.statePath()
+	if len(statePath) == 0 {
+		return nil, fmt.Errorf("no state path. Call DepManager.SetStatePath first")
+	}
+
+	records, err := loadRecords(statePath)
+	if err != nil {
+		return nil, fmt.Errorf("loadRecords('%s'): %w", statePath, err)
+	}
+
+	report := &Reconciliation{
+		Adopted: make([]string, 0, len(records)),
+		Killed:  make([]string, 0),
+		Removed: make([]string, 0),
+	}
+	for _, record := range records {
+		manager.mu.RLock()
+		_, ok := manager.runningDeps[record.Id]
+		manager.mu.RUnlock()
+		if ok {
+			continue
+		}
+
+		if !processAlive(record.Pid) || !processIsBinary(record.Pid, record.BinPath) {
+			if err := deleteRecord(statePath, record.Id); err != nil {
+				return report, fmt.Errorf("deleteRecord('%s'): %w", record.Id, err)
+			}
+			report.Removed = append(report.Removed, record.Id)
+			continue
+		}
+
+		adopt := record.Detached && record.Manager == nil
+		if record.Manager != nil {
+			adopt, err = manager.Running(record.Manager)
+			if err != nil {
+				return report, fmt.Errorf("manager.Running('%s'): %w", record.Id, err)
+			}
+		}
+
+		if !adopt {
+			// the children of the dependency are killed along with it
+			if err := killProcessGroup(record.Pid); err != nil {
+				return report, fmt.Errorf("killProcessGroup(id='%s', pid=%d): %w", record.Id, record.Pid, err)
+			}
+			if err := deleteRecord(statePath, record.Id); err != nil {
+				return report, fmt.Errorf("deleteRecord('%s'): %w", record.Id, err)
+			}
+			report.Killed = append(report.Killed, record.Id)
+			continue
+		}
+
+		src, err := source.New(record.Url)
+		if err != nil {
+			return report, fmt.Errorf("source.New('%s'): %w", record.Url, err)
+		}
+		manager.mu.Lock()
+		manager.runningDeps[record.Id] = &Dep{
+			Src:       src,
+			binPath:   record.BinPath,
+			detached:  record.Detached,
+			manager:   record.Manager,
+			pid:       record.Pid,
+			args:      record.Args,
+			startTime: record.StartTime,
+			done:      make(chan error, 1),
+			exited:    make(chan struct{}),
+		}
+		manager.mu.Unlock()
+		manager.watch(record.Id)
+
+		report.Adopted = append(report.Adopted, record.Id)
+	}
+
+	return report, nil
+}
+
+// processIsBinary checks that the process runs the binary.
+// It protects from killing the unrelated process that reused the pid of the exited dependency.
+//
+// If the operating system doesn't expose the executable of the process, it's assumed to be the binary.
+func processIsBinary(pid int, binPath string) bool {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil || len(binPath) == 0 {
+		return true
+	}
+	// the binary was re-built while the process is running
+	exe = strings.TrimSuffix(exe, " (deleted)")
+
+	absBin, err := filepath.Abs(binPath)
+	if err != nil {
+		return true
+	}
+
+	return filepath.Clean(exe) == filepath.Clean(absBin)
+}
+
+// recordPath returns the file path of the record by the dependency id
+func recordPath(statePath string, id string) string {
+	return filepath.Join(statePath, urlToFileName(id)+recordExt)
+}
+
+// saveRecord writes the record into the state directory.
+// The record is written into the temporary file first, then renamed. So, the half-written records are not possible.
+func saveRecord(statePath string, record *Record) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	filePath := recordPath(statePath, record.Id)
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("os.WriteFile('%s'): %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("os.Rename('%s', '%s'): %w", tmpPath, filePath, err)
+	}
+
+	return nil
+}
+
+// deleteRecord removes the record from the state directory.
+// Deleting non-existing record is not an error.
+func deleteRecord(statePath string, id string) error {
+	filePath := recordPath(statePath, id)
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Remove('%s'): %w", filePath, err)
+	}
+
+	return nil
+}
+
+// loadRecords returns all records in the state directory.
+func loadRecords(statePath string) ([]*Record, error) {
+	entries, err := os.ReadDir(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Record{}, nil
+		}
+		return nil, fmt.Errorf("os.ReadDir('%s'): %w", statePath, err)
+	}
+
+	records := make([]*Record, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordExt) {
+			continue
+		}
+
+		filePath := filepath.Join(statePath, entry.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile('%s'): %w", filePath, err)
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal('%s'): %w", filePath, err)
+		}
+		if record.Parent != nil {
+			record.Parent.UrlFunc(clientConfig.Url)
+		}
+		if record.Manager != nil {
+			record.Manager.UrlFunc(clientConfig.Url)
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
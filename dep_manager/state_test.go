@@ -0,0 +1,150 @@
+package dep_manager
+
+import (
+	"bytes"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestStateSuite struct {
+	suite.Suite
+
+	statePath string
+	url       string
+}
+
+func (test *TestStateSuite) SetupTest() {
+	test.statePath = test.T().TempDir()
+	test.url = "github.com/ahmetson/test-manager"
+}
+
+// Test_10_Record tests saving, loading and deleting the records
+func (test *TestStateSuite) Test_10_Record() {
+	s := test.Require
+
+	records, err := loadRecords(test.statePath)
+	s().NoError(err)
+	s().Len(records, 0)
+
+	// non-existing directory has no records
+	records, err = loadRecords(test.statePath + "_not_exist")
+	s().NoError(err)
+	s().Len(records, 0)
+
+	record := &Record{
+		Id:        "test-manager",
+		Url:       test.url,
+		Pid:       100,
+		BinPath:   "bin/test",
+		Args:      []string{"--id=test-manager"},
+		Parent:    &clientConfig.Client{ServiceUrl: "dev-lib", Id: "parent", Port: 120},
+		Detached:  true,
+		StartTime: time.Now(),
+	}
+	s().NoError(saveRecord(test.statePath, record))
+
+	records, err = loadRecords(test.statePath)
+	s().NoError(err)
+	s().Len(records, 1)
+	s().Equal(record.Id, records[0].Id)
+	s().Equal(record.Pid, records[0].Pid)
+	s().Equal(record.Args, records[0].Args)
+	s().Equal(record.Parent.Id, records[0].Parent.Id)
+	s().NotEmpty(records[0].Parent.Url())
+	s().Nil(records[0].Manager)
+
+	// over-write
+	record.Pid = 200
+	s().NoError(saveRecord(test.statePath, record))
+	records, err = loadRecords(test.statePath)
+	s().NoError(err)
+	s().Len(records, 1)
+	s().Equal(200, records[0].Pid)
+
+	s().NoError(deleteRecord(test.statePath, record.Id))
+	records, err = loadRecords(test.statePath)
+	s().NoError(err)
+	s().Len(records, 0)
+
+	// deleting twice has no effect
+	s().NoError(deleteRecord(test.statePath, record.Id))
+}
+
+// Test_11_Reconcile tests adopting, killing and removing the dependencies left by the previous DepManager
+func (test *TestStateSuite) Test_11_Reconcile() {
+	s := test.Require
+
+	depManager := New()
+
+	// the state path is required
+	_, err := depManager.Reconcile()
+	s().Error(err)
+	s().NoError(depManager.SetStatePath(test.statePath))
+
+	// the current process is alive for sure
+	alive := &Record{Id: "alive", Url: test.url, Pid: os.Getpid(), Detached: true}
+	s().NoError(saveRecord(test.statePath, alive))
+	dead := &Record{Id: "dead", Url: test.url, Pid: -1, Detached: true}
+	s().NoError(saveRecord(test.statePath, dead))
+
+	// the orphan that is not detached, and can not answer the heartbeat.
+	// its child keeps the output open until it's killed too.
+	var orphan *exec.Cmd
+	if runtime.GOOS != "windows" {
+		orphan = exec.Command("sh", "-c", "sleep 30 &\nwait")
+		orphan.Stdout = &bytes.Buffer{}
+		orphan.SysProcAttr = groupAttr()
+		s().NoError(orphan.Start())
+		// let the orphan spawn its child
+		time.Sleep(time.Millisecond * 100)
+		stale := &Record{Id: "stale", Url: test.url, Pid: orphan.Process.Pid}
+		s().NoError(saveRecord(test.statePath, stale))
+	}
+
+	report, err := depManager.Reconcile()
+	s().NoError(err)
+	s().False(report.IsEmpty())
+	s().Equal([]string{alive.Id}, report.Adopted)
+	s().Equal([]string{dead.Id}, report.Removed)
+	s().NotNil(depManager.OnStop(alive.Id))
+	s().Nil(depManager.OnStop(dead.Id))
+
+	if orphan != nil {
+		s().Equal([]string{"stale"}, report.Killed)
+		waited := make(chan error, 1)
+		go func() {
+			waited <- orphan.Wait()
+		}()
+		select {
+		case err := <-waited:
+			s().Error(err)
+		case <-time.After(time.Second * 5):
+			s().Fail("the child of the orphan is not killed")
+		}
+	}
+
+	// only the adopted record is kept
+	records, err := loadRecords(test.statePath)
+	s().NoError(err)
+	s().Len(records, 1)
+	s().Equal(alive.Id, records[0].Id)
+
+	// the adopted dependency is not adopted twice
+	report, err = depManager.Reconcile()
+	s().NoError(err)
+	s().True(report.IsEmpty())
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestState(t *testing.T) {
+	suite.Run(t, new(TestStateSuite))
+}
@@ -0,0 +1,184 @@
+package dep_manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ahmetson/client-lib"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/datatype-lib/data_type/key_value"
+	"github.com/ahmetson/datatype-lib/message"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// StopStage is the step of the stop sequence that stopped the dependency
+type StopStage = string
+
+const (
+	StopClosed     StopStage = "closed"     // the dependency exited after the close command
+	StopTerminated StopStage = "terminated" // the dependency exited after the termination signal
+	StopKilled     StopStage = "killed"     // the process group of the dependency was killed
+)
+
+// The default time to wait for the dependency to exit after each step of the stop sequence
+const (
+	DefaultCloseGrace = time.Second * 5
+	DefaultTermGrace  = time.Second * 5
+)
+
+// killWait is the time to wait for the killed dependency to exit.
+// The adopted dependency is polled, so it's longer than the watchInterval.
+const killWait = time.Second * 5
+
+// An ExitStatus is how the spawned dependency exited
+type ExitStatus struct {
+	Code   int       `json:"code"`             // the exit code, -1 if the process was killed by a signal
+	Signal string    `json:"signal,omitempty"` // the signal that killed the process
+	Stage  StopStage `json:"stage,omitempty"`  // set if the dependency was stopped by DepManager.Stop
+}
+
+// newExitStatus returns the exit status by the error of cmd.Wait.
+// The exit of the adopted dependency can't be waited, so its code is always 0.
+func newExitStatus(err error) *ExitStatus {
+	if err == nil {
+		return &ExitStatus{Code: 0}
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return &ExitStatus{Code: -1}
+	}
+
+	status := &ExitStatus{Code: exitErr.ExitCode()}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = ws.Signal().String()
+	}
+
+	return status
+}
+
+// SetGracePeriods sets the time to wait for the dependency to exit after the close command,
+// and after the termination signal. See DepManager.Stop.
+func (manager *DepManager) SetGracePeriods(closeGrace time.Duration, termGrace time.Duration) {
+	manager.mu.Lock()
+	manager.closeGrace = closeGrace
+	manager.termGrace = termGrace
+	manager.mu.Unlock()
+}
+
+// Stop the dependency spawned or adopted by this DepManager.
+// The dependency is stopped in the steps, and each next step is done if the dependency is still running:
+//   - the close command is sent, if the dependency has a socket. Then waits the close grace period.
+//   - the termination signal is sent to the process group. Then waits the termination grace period.
+//   - the process group is killed.
+//
+// The stopped dependency is not restarted by its RestartPolicy.
+// The dependency that already exited and waits for the restart is not signaled, its restart is canceled.
+//
+// Returns the exit status of the dependency with the step that stopped it.
+// The step is empty if the dependency exited before the stop.
+func (manager *DepManager) Stop(id string) (*ExitStatus, error) {
+	manager.mu.RLock()
+	instance, ok := manager.runningDeps[id]
+	var c *clientConfig.Client
+	if ok {
+		c = instance.manager
+	}
+	manager.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("the '%s' dep is not running", id)
+	}
+
+	return manager.stop(id, c)
+}
+
+// The stop runs the stop sequence. The close command is sent through the given socket, if it's not nil.
+func (manager *DepManager) stop(id string, c *clientConfig.Client) (*ExitStatus, error) {
+	manager.mu.Lock()
+	instance, ok := manager.runningDeps[id]
+	if !ok || instance.pid == 0 {
+		manager.mu.Unlock()
+		return nil, fmt.Errorf("the '%s' dep is not running", id)
+	}
+	// the exited dependency waiting for the restart has no process to signal
+	respawn := instance.respawn
+	if respawn != nil && !instance.stopping {
+		close(respawn)
+	}
+	instance.stopping = true
+	pid := instance.pid
+	exited := instance.exited
+	closeGrace := manager.closeGrace
+	termGrace := manager.termGrace
+	manager.mu.Unlock()
+
+	if respawn != nil {
+		if !waitExit(exited, killWait) {
+			return nil, fmt.Errorf("%w: the '%s' dep is not released after the restart was canceled", ErrTimeout, id)
+		}
+		manager.mu.RLock()
+		status := *instance.exitStatus
+		manager.mu.RUnlock()
+		return &status, nil
+	}
+
+	stage := StopClosed
+	stopped := false
+	if c != nil {
+		manager.sendClose(c)
+		stopped = waitExit(exited, closeGrace)
+	}
+
+	if !stopped {
+		stage = StopTerminated
+		if err := terminateProcess(pid); err != nil {
+			return nil, fmt.Errorf("terminateProcess(%d): %w", pid, err)
+		}
+		stopped = waitExit(exited, termGrace)
+	}
+
+	if !stopped {
+		stage = StopKilled
+		if err := killProcessGroup(pid); err != nil {
+			return nil, fmt.Errorf("killProcessGroup(%d): %w", pid, err)
+		}
+		if !waitExit(exited, killWait) {
+			return nil, fmt.Errorf("%w: the '%s' dep is running even after killing", ErrTimeout, id)
+		}
+	}
+
+	manager.mu.RLock()
+	status := *instance.exitStatus
+	manager.mu.RUnlock()
+	status.Stage = stage
+
+	return &status, nil
+}
+
+// sendClose sends the close command to the dependency.
+// The dependency closes without replying, so the result is not checked.
+func (manager *DepManager) sendClose(c *clientConfig.Client) {
+	sock, err := client.New(c)
+	if err != nil {
+		return
+	}
+
+	closeRequest := &message.Request{
+		Command:    "close",
+		Parameters: key_value.New(),
+	}
+	sock.Timeout(manager.timeout).Attempt(1)
+	_, _ = sock.Request(closeRequest)
+	_ = sock.Close()
+}
+
+// waitExit returns true if the dependency exited within the timeout
+func waitExit(exited chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,288 @@
+//go:build !windows
+// +build !windows
+
+package dep_manager
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/dev-lib/source"
+	handlerConfig "github.com/ahmetson/handler-lib/config"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestStopSuite struct {
+	suite.Suite
+
+	manager *DepManager
+}
+
+func (test *TestStopSuite) SetupTest() {
+	test.manager = New()
+}
+
+// spawn runs the shell script as the dependency
+func (test *TestStopSuite) spawn(id string, script string) {
+	s := test.Require
+
+	binPath := filepath.Join(test.T().TempDir(), id)
+	s().NoError(os.WriteFile(binPath, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+
+	instance := &Dep{binPath: binPath, done: make(chan error, 1), exited: make(chan struct{})}
+	test.manager.runningDeps[id] = instance
+	s().NoError(test.manager.start(id, instance))
+	test.manager.wait(id)
+
+	// let the script set up its signal handlers
+	time.Sleep(time.Millisecond * 200)
+}
+
+// Test_10_NewExitStatus tests the exit status by the wait error
+func (test *TestStopSuite) Test_10_NewExitStatus() {
+	s := test.Require
+
+	status := newExitStatus(nil)
+	s().Equal(0, status.Code)
+	s().Empty(status.Signal)
+
+	status = newExitStatus(fmt.Errorf("unknown"))
+	s().Equal(-1, status.Code)
+}
+
+// Test_11_Terminate tests that the dependency without the socket is stopped by the termination signal
+func (test *TestStopSuite) Test_11_Terminate() {
+	s := test.Require
+
+	id := "terminated"
+	test.spawn(id, "exec sleep 60")
+	test.manager.SetGracePeriods(time.Millisecond*100, time.Second*2)
+	onStop := test.manager.OnStop(id)
+	s().NotNil(onStop)
+
+	status, err := test.manager.Stop(id)
+	s().NoError(err)
+	s().Equal(StopTerminated, status.Stage)
+	s().Equal(-1, status.Code)
+	s().Equal("terminated", status.Signal)
+
+	s().Error(<-onStop)
+
+	// already stopped
+	_, err = test.manager.Stop(id)
+	s().Error(err)
+}
+
+// Test_12_Kill tests that the dependency ignoring the termination signal is killed
+func (test *TestStopSuite) Test_12_Kill() {
+	s := test.Require
+
+	id := "killed"
+	test.spawn(id, "trap '' TERM\nwhile true; do sleep 1; done")
+	test.manager.SetGracePeriods(time.Millisecond*100, time.Millisecond*100)
+
+	status, err := test.manager.Stop(id)
+	s().NoError(err)
+	s().Equal(StopKilled, status.Stage)
+	s().Equal("killed", status.Signal)
+}
+
+// Test_13_Info tests the description of the running dependencies
+func (test *TestStopSuite) Test_13_Info() {
+	s := test.Require
+
+	_, err := test.manager.Info("first")
+	s().Error(err)
+	s().Empty(test.manager.List())
+
+	test.spawn("second", "exec sleep 60")
+	test.spawn("first", "exec sleep 60")
+	test.manager.SetGracePeriods(0, time.Second*2)
+
+	info, err := test.manager.Info("first")
+	s().NoError(err)
+	s().Equal("first", info.Id)
+	s().NotZero(info.Pid)
+	s().NotEmpty(info.BinPath)
+	s().Greater(info.Uptime, time.Duration(0))
+	s().Zero(info.Restarts)
+	s().Nil(info.LastExit)
+
+	infos := test.manager.List()
+	s().Len(infos, 2)
+	s().Equal("first", infos[0].Id)
+	s().Equal("second", infos[1].Id)
+
+	for _, info := range infos {
+		_, err = test.manager.Stop(info.Id)
+		s().NoError(err)
+	}
+	s().Empty(test.manager.List())
+}
+
+// Test_14_WaitReady tests waiting for the dependency that never replies
+func (test *TestStopSuite) Test_14_WaitReady() {
+	s := test.Require
+
+	test.manager.timeout = time.Millisecond * 50
+	test.manager.SetGracePeriods(0, time.Second*2)
+	c := &clientConfig.Client{
+		Id:         "not-replying",
+		Port:       6099,
+		TargetType: handlerConfig.SocketType(handlerConfig.ReplierType),
+	}
+
+	// no socket
+	test.spawn("not-replying", "exec sleep 60")
+	s().Error(test.manager.WaitReady("not-replying", nil, time.Millisecond*200))
+
+	events := test.manager.OnEvent("not-replying")
+	err := test.manager.WaitReady("not-replying", c, time.Millisecond*200)
+	s().ErrorIs(err, ErrTimeout)
+	event := <-events
+	s().Equal(EventNotReady, event.Type)
+	s().ErrorIs(event.Err, ErrTimeout)
+
+	// the dependency exits before the timeout
+	test.spawn("crashed", "sleep 0.3\nexit 1")
+	err = test.manager.WaitReady("crashed", c, time.Second*10)
+	s().ErrorIs(err, ErrExited)
+
+	_, err = test.manager.Stop("not-replying")
+	s().NoError(err)
+}
+
+// Test_15_StopSiblings tests that the group kills the running sibling without recording it as a crash
+func (test *TestStopSuite) Test_15_StopSiblings() {
+	s := test.Require
+
+	test.manager.SetGracePeriods(0, time.Second*2)
+	s().NoError(test.manager.SetGroup(NewGroup("storage", OneForAll, "db", "auth")))
+	test.spawn("db", "sleep 60 &\nwait")
+	test.spawn("auth", "exec sleep 60")
+
+	s().Equal([]string{"db", "auth"}, test.manager.stopSiblings("auth"))
+	s().Empty(test.manager.CrashReports("db"))
+
+	_, err := test.manager.Stop("auth")
+	s().NoError(err)
+}
+
+// Test_16_StopRestarting tests that the crashed dependency waiting for the restart is stopped without the signals
+func (test *TestStopSuite) Test_16_StopRestarting() {
+	s := test.Require
+
+	policy := NewRestartPolicy(RestartOnFailure)
+	policy.Backoff = time.Minute
+	policy.MaxBackoff = time.Minute
+	s().NoError(test.manager.SetRestartPolicy("crashing", policy))
+	events := test.manager.OnEvent("crashing")
+	test.spawn("crashing", "exit 1")
+
+	event := <-events
+	s().Equal(EventRestarting, event.Type)
+
+	started := time.Now()
+	status, err := test.manager.Stop("crashing")
+	s().NoError(err)
+	s().Less(time.Since(started), time.Second)
+	s().Equal(1, status.Code)
+	s().Empty(status.Stage)
+	s().Empty(test.manager.List())
+}
+
+// Test_17_RunGraphNotReady tests that the dependency spawned by RunGraph, but not ready, is stopped
+func (test *TestStopSuite) Test_17_RunGraphNotReady() {
+	s := test.Require
+
+	test.manager.timeout = time.Millisecond * 50
+	test.manager.SetGracePeriods(0, time.Second*2)
+	s().NoError(test.manager.SetGraph(NewGraph().Require("proxy", "db")))
+
+	binPath := filepath.Join(test.T().TempDir(), "db")
+	s().NoError(os.WriteFile(binPath, []byte("#!/bin/sh\nexec sleep 60\n"), 0755))
+	dep := &Dep{
+		Src:     &source.Src{Url: "github.com/ahmetson/test-manager"},
+		srcPath: test.T().TempDir(),
+		binPath: binPath,
+	}
+	dep.SetManager(&clientConfig.Client{
+		Id:         "db",
+		Port:       6098,
+		TargetType: handlerConfig.SocketType(handlerConfig.ReplierType),
+	})
+	dep.SetReadyTimeout(time.Millisecond * 300)
+
+	err := test.manager.RunGraph(map[string]*Dep{"db": dep})
+	s().ErrorIs(err, ErrTimeout)
+	s().Empty(test.manager.List())
+}
+
+// Test_18_RunNotReady tests that the dependency that is not ready is stopped by Run
+func (test *TestStopSuite) Test_18_RunNotReady() {
+	s := test.Require
+
+	test.manager.timeout = time.Millisecond * 50
+	test.manager.SetGracePeriods(0, time.Second*2)
+
+	binPath := filepath.Join(test.T().TempDir(), "db")
+	s().NoError(os.WriteFile(binPath, []byte("#!/bin/sh\nexec sleep 60\n"), 0755))
+	dep := &Dep{
+		Src:     &source.Src{Url: "github.com/ahmetson/test-manager"},
+		srcPath: test.T().TempDir(),
+		binPath: binPath,
+	}
+	dep.SetManager(&clientConfig.Client{
+		Id:         "db",
+		Port:       6097,
+		TargetType: handlerConfig.SocketType(handlerConfig.ReplierType),
+	})
+	dep.SetReadyTimeout(time.Millisecond * 300)
+
+	s().ErrorIs(test.manager.Run(dep, "db"), ErrTimeout)
+	s().Empty(test.manager.List())
+}
+
+// Test_19_StopSiblingsKillFailed tests that the sibling that wasn't killed is released when it exits by itself
+func (test *TestStopSuite) Test_19_StopSiblingsKillFailed() {
+	s := test.Require
+
+	defer func() { killSibling = killProcessGroup }()
+	killSibling = func(int) error {
+		// the sibling exits by itself, while the group is stopping it
+		time.Sleep(time.Millisecond * 1500)
+		return fmt.Errorf("operation not permitted")
+	}
+
+	s().NoError(test.manager.SetGroup(NewGroup("storage", OneForAll, "db", "auth")))
+	test.spawn("db", "sleep 1\nexit 3")
+	test.spawn("auth", "exec sleep 60")
+	db := test.manager.runningDeps["db"]
+
+	s().Equal([]string{"auth"}, test.manager.stopSiblings("auth"))
+
+	select {
+	case <-db.exited:
+	case <-time.After(time.Second * 2):
+		s().Fail("the sibling is not released")
+	}
+	s().Equal(3, db.exitStatus.Code)
+	s().Len(test.manager.CrashReports("db"), 1)
+	s().Len(test.manager.List(), 1)
+
+	killSibling = killProcessGroup
+	_, err := test.manager.Stop("auth")
+	s().NoError(err)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestStop(t *testing.T) {
+	suite.Run(t, new(TestStopSuite))
+}
@@ -0,0 +1,268 @@
+package dep_manager
+
+import (
+	"fmt"
+	"time"
+)
+
+// Restart defines when the exited dependency is restarted
+type Restart = string
+
+const (
+	RestartNever     Restart = "never"      // the exited dependency is not restarted
+	RestartOnFailure Restart = "on-failure" // the dependency is restarted if it exits with an error
+	RestartAlways    Restart = "always"     // the dependency is restarted whenever it exits
+)
+
+const (
+	// DefaultBackoff is the delay before the first restart
+	DefaultBackoff = time.Second
+	// DefaultMaxBackoff is the limit of the delay between the restarts
+	DefaultMaxBackoff = time.Second * 30
+	// DefaultMaxRestarts is the amount of the restarts within DefaultRestartWindow.
+	// If the dependency crashes more often, then it's marked as failed.
+	DefaultMaxRestarts = 5
+	// DefaultRestartWindow is the period to count the restarts
+	DefaultRestartWindow = time.Minute
+)
+
+// A RestartPolicy defines when and how often the exited dependency is restarted by the DepManager.
+//
+// The delay before the restart starts with Backoff, and doubles for each restart within the Window.
+// If the dependency was restarted MaxRestarts times within the Window, then it's in a crash loop.
+// The dependency in the crash loop is marked as failed, and not restarted anymore.
+type RestartPolicy struct {
+	Restart     Restart       `json:"restart"`
+	MaxRestarts uint64        `json:"max_restarts"`
+	Window      time.Duration `json:"window"`
+	Backoff     time.Duration `json:"backoff"`
+	MaxBackoff  time.Duration `json:"max_backoff"`
+}
+
+// A RestartStatus is the supervision state of the dependency
+type RestartStatus struct {
+	Id        string `json:"id"`
+	Restarts  uint64 `json:"restarts"`             // total amount of the restarts since DepManager.Run
+	Failed    bool   `json:"failed"`               // the dependency crashed too often, and it's not restarted anymore
+	LastError string `json:"last_error,omitempty"` // the last exit error of the dependency
+}
+
+// the supervisor keeps the restart history of the dependency
+type supervisor struct {
+	restarts []time.Time // the restart times within the window
+	total    uint64
+	failed   bool
+	lastErr  error
+	lastExit *ExitStatus // the exit status of the last crash
+}
+
+// NewRestartPolicy returns the policy with the default limits
+func NewRestartPolicy(restart Restart) *RestartPolicy {
+	return &RestartPolicy{
+		Restart:     restart,
+		MaxRestarts: DefaultMaxRestarts,
+		Window:      DefaultRestartWindow,
+		Backoff:     DefaultBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// IsValid returns an error if the policy has unknown Restart mode or invalid limits
+func (policy *RestartPolicy) IsValid() error {
+	if policy == nil {
+		return fmt.Errorf("nil")
+	}
+	if policy.Restart != RestartNever && policy.Restart != RestartOnFailure && policy.Restart != RestartAlways {
+		return fmt.Errorf("unknown '%s' restart, expected '%s', '%s' or '%s'",
+			policy.Restart, RestartNever, RestartOnFailure, RestartAlways)
+	}
+	if policy.Restart == RestartNever {
+		return nil
+	}
+	if policy.MaxRestarts == 0 {
+		return fmt.Errorf("max restarts must be positive")
+	}
+	if policy.Window <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+	if policy.Backoff < 0 || policy.MaxBackoff < policy.Backoff {
+		return fmt.Errorf("backoff must be between 0 and max backoff")
+	}
+
+	return nil
+}
+
+// shouldRestart returns true if the dependency exited with the given error must be restarted
+func (policy *RestartPolicy) shouldRestart(exitErr error) bool {
+	switch policy.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitErr != nil
+	default:
+		return false
+	}
+}
+
+// delay returns the exponential backoff before the restart attempt that starts from 0.
+func (policy *RestartPolicy) delay(attempt uint64) time.Duration {
+	delay := policy.Backoff
+	for i := uint64(0); i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	return delay
+}
+
+// prune removes the restarts that are out of the window
+func (sup *supervisor) prune(now time.Time, window time.Duration) {
+	recent := make([]time.Time, 0, len(sup.restarts))
+	for _, restartTime := range sup.restarts {
+		if now.Sub(restartTime) < window {
+			recent = append(recent, restartTime)
+		}
+	}
+	sup.restarts = recent
+}
+
+// SetRestartPolicy sets the policy of the dependency by its id.
+// The policy is applied to the dependency that exits after this call.
+// Pass nil to remove the policy, which is the same as RestartNever.
+func (manager *DepManager) SetRestartPolicy(id string, policy *RestartPolicy) error {
+	if manager == nil || len(id) == 0 {
+		return fmt.Errorf("nil or no id")
+	}
+	if policy != nil {
+		if err := policy.IsValid(); err != nil {
+			return fmt.Errorf("policy.IsValid: %w", err)
+		}
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if policy == nil {
+		delete(manager.policies, id)
+		return nil
+	}
+	manager.policies[id] = policy
+
+	return nil
+}
+
+// RestartStatus returns the supervision state of the dependency.
+// The status is kept after the dependency fails, until it's run again.
+func (manager *DepManager) RestartStatus(id string) *RestartStatus {
+	status := &RestartStatus{Id: id}
+	if manager == nil {
+		return status
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	sup, ok := manager.supervisors[id]
+	if !ok {
+		return status
+	}
+	status.Restarts = sup.total
+	status.Failed = sup.failed
+	if sup.lastErr != nil {
+		status.LastError = sup.lastErr.Error()
+	}
+
+	return status
+}
+
+// supervise decides whether the exited dependency is restarted.
+// It blocks for the backoff delay, then spawns the dependency again.
+// DepManager.Stop cancels the backoff.
+//
+// If the dependency is the member of the group, then the siblings are restarted by the group Strategy.
+//
+// Returns true if the dependency was restarted, then the caller must not clean up the dependency.
+func (manager *DepManager) supervise(id string, instance *Dep, exitErr error) bool {
+	manager.mu.Lock()
+
+	policy := manager.restartPolicy(id)
+	if policy == nil || instance.stopping || !policy.shouldRestart(exitErr) {
+		manager.mu.Unlock()
+		return false
+	}
+
+	sup, ok := manager.supervisors[id]
+	if !ok {
+		sup = &supervisor{restarts: make([]time.Time, 0, policy.MaxRestarts)}
+		manager.supervisors[id] = sup
+	}
+	sup.lastErr = exitErr
+	sup.lastExit = newExitStatus(exitErr)
+
+	now := time.Now()
+	sup.prune(now, policy.Window)
+	attempt := uint64(len(sup.restarts))
+	if attempt >= policy.MaxRestarts {
+		sup.failed = true
+		manager.mu.Unlock()
+		manager.emit(&Event{
+			Id:      id,
+			Type:    EventFailed,
+			Attempt: attempt,
+			Err:     fmt.Errorf("crash loop: restarted %d times within %s: %v", attempt, policy.Window, exitErr),
+		})
+		return false
+	}
+
+	manager.mu.Unlock()
+
+	restarts := manager.stopSiblings(id)
+
+	manager.mu.RLock()
+	respawn := instance.respawn
+	manager.mu.RUnlock()
+
+	delay := policy.delay(attempt)
+	manager.emit(&Event{Id: id, Type: EventRestarting, Attempt: attempt + 1, Delay: delay, Err: exitErr})
+	select {
+	case <-time.After(delay):
+	case <-respawn:
+	}
+
+	restarted := false
+	for _, restartId := range restarts {
+		if restartId != id {
+			manager.restartSibling(restartId)
+			continue
+		}
+
+		// closed during the backoff
+		manager.mu.RLock()
+		stopping := instance.stopping
+		manager.mu.RUnlock()
+		if stopping {
+			continue
+		}
+
+		if err := manager.start(id, instance); err != nil {
+			manager.mu.Lock()
+			sup.failed = true
+			sup.lastErr = err
+			manager.mu.Unlock()
+			manager.emit(&Event{Id: id, Type: EventFailed, Attempt: attempt + 1, Err: fmt.Errorf("manager.start: %w", err)})
+			continue
+		}
+		manager.mu.Lock()
+		sup.restarts = append(sup.restarts, now)
+		sup.total++
+		manager.mu.Unlock()
+
+		manager.emit(&Event{Id: id, Type: EventRestarted, Attempt: attempt + 1})
+		manager.wait(id)
+		restarted = true
+	}
+
+	return restarted
+}
@@ -0,0 +1,114 @@
+package dep_manager
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestSupervisorSuite struct {
+	suite.Suite
+}
+
+// Test_10_IsValid tests the validation of the restart policy
+func (test *TestSupervisorSuite) Test_10_IsValid() {
+	s := test.Require
+
+	var policy *RestartPolicy
+	s().Error(policy.IsValid())
+
+	// the unknown restart mode
+	policy = NewRestartPolicy("sometimes")
+	s().Error(policy.IsValid())
+
+	policy = NewRestartPolicy(RestartOnFailure)
+	s().NoError(policy.IsValid())
+
+	// the never restarted dependency doesn't need the limits
+	policy = &RestartPolicy{Restart: RestartNever}
+	s().NoError(policy.IsValid())
+
+	policy = NewRestartPolicy(RestartAlways)
+	policy.MaxRestarts = 0
+	s().Error(policy.IsValid())
+
+	policy = NewRestartPolicy(RestartAlways)
+	policy.Window = 0
+	s().Error(policy.IsValid())
+
+	policy = NewRestartPolicy(RestartAlways)
+	policy.MaxBackoff = policy.Backoff - 1
+	s().Error(policy.IsValid())
+}
+
+// Test_11_ShouldRestart tests the restart decision by the exit error
+func (test *TestSupervisorSuite) Test_11_ShouldRestart() {
+	s := test.Require
+
+	exitErr := fmt.Errorf("exit status 1")
+
+	policy := NewRestartPolicy(RestartNever)
+	s().False(policy.shouldRestart(nil))
+	s().False(policy.shouldRestart(exitErr))
+
+	policy = NewRestartPolicy(RestartOnFailure)
+	s().False(policy.shouldRestart(nil))
+	s().True(policy.shouldRestart(exitErr))
+
+	policy = NewRestartPolicy(RestartAlways)
+	s().True(policy.shouldRestart(nil))
+	s().True(policy.shouldRestart(exitErr))
+}
+
+// Test_12_Delay tests the exponential backoff limited by the max backoff
+func (test *TestSupervisorSuite) Test_12_Delay() {
+	s := test.Require
+
+	policy := NewRestartPolicy(RestartAlways)
+	s().Equal(DefaultBackoff, policy.delay(0))
+	s().Equal(DefaultBackoff*2, policy.delay(1))
+	s().Equal(DefaultBackoff*4, policy.delay(2))
+	s().Equal(DefaultMaxBackoff, policy.delay(10))
+	s().Equal(DefaultMaxBackoff, policy.delay(1000))
+}
+
+// Test_13_Prune tests that the restarts out of the window are not counted
+func (test *TestSupervisorSuite) Test_13_Prune() {
+	s := test.Require
+
+	now := time.Now()
+	sup := &supervisor{
+		restarts: []time.Time{now.Add(-time.Minute * 2), now.Add(-time.Second * 30), now},
+	}
+	sup.prune(now, time.Minute)
+	s().Len(sup.restarts, 2)
+}
+
+// Test_14_SetRestartPolicy tests setting and removing the policy
+func (test *TestSupervisorSuite) Test_14_SetRestartPolicy() {
+	s := test.Require
+
+	manager := New()
+	id := "test-manager"
+
+	s().Error(manager.SetRestartPolicy(id, NewRestartPolicy("sometimes")))
+	s().NoError(manager.SetRestartPolicy(id, NewRestartPolicy(RestartOnFailure)))
+
+	status := manager.RestartStatus(id)
+	s().Equal(id, status.Id)
+	s().Zero(status.Restarts)
+	s().False(status.Failed)
+
+	// removing the policy
+	s().NoError(manager.SetRestartPolicy(id, nil))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestSupervisor(t *testing.T) {
+	suite.Run(t, new(TestSupervisorSuite))
+}
@@ -0,0 +1,250 @@
+package dep_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/ahmetson/log-lib"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"sort"
+	"strings"
+)
+
+// generatedFiles are changed by the build itself with 'go mod tidy', so they don't make the checkout dirty
+var generatedFiles = map[string]bool{
+	"go.mod": true,
+	"go.sum": true,
+}
+
+// syncSrc switches the existing source code to the Tag, Commit or Branch of the dependency.
+// The remote repository is fetched only if the requested version is not in the checkout.
+// The dependency without the version is switched to the default branch of the remote repository.
+//
+// The checkout with the uncommitted changes is not switched, and ErrSourceDirty is returned.
+// The changes of go.mod and go.sum are discarded, as they are made by the build.
+//
+// Since it's a private method, it assumes the source code is manageable, and the caller locked its path.
+func (manager *DepManager) syncSrc(ctx context.Context, dep *Dep, logger *log.Logger) error {
+	repo, err := git.PlainOpen(dep.srcPath)
+	if err == git.ErrRepositoryNotExists {
+		// the source code was not cloned by the DepManager
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("git.PlainOpen('%s'): %w", dep.srcPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("repo.Head: %w", err)
+	}
+
+	at, err := atRef(ctx, repo, head, dep, logger)
+	if err != nil {
+		return fmt.Errorf("atRef: %w", err)
+	}
+	if at {
+		return nil
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("repo.Worktree: %w", err)
+	}
+	if err := checkClean(worktree); err != nil {
+		return fmt.Errorf("checkClean('%s'): %w", dep.srcPath, err)
+	}
+
+	logger.Info("switching the source code", "from", head.Name().Short(), "to", dep.Ref().String())
+	if err := fetchSrc(ctx, repo, dep, logger); err != nil {
+		return fmt.Errorf("fetchSrc: %w", err)
+	}
+	if err := switchRef(ctx, repo, worktree, dep); err != nil {
+		return fmt.Errorf("switchRef: %w", err)
+	}
+
+	return nil
+}
+
+// fetchSrc downloads the new commits and tags of the remote repository.
+// The moved tags are overwritten.
+func fetchSrc(ctx context.Context, repo *git.Repository, dep *Dep, logger *log.Logger) error {
+	if err := dep.stage(StageCloning); err != nil {
+		return fmt.Errorf("dep.stage('%s'): %w", StageCloning, err)
+	}
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Tags:       git.AllTags,
+		Force:      true,
+		Progress:   withProgress(logger.Child("fetch"), dep.progress),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return fmt.Errorf("repo.FetchContext: %w", ctxErr)
+		}
+		return newError(ErrCloneFailed, fmt.Errorf("repo.FetchContext: %w", err))
+	}
+
+	return nil
+}
+
+// atRef returns true if the checkout is at the requested version already.
+// The branch is not compared with the remote, so the checkout on the requested branch is not updated.
+// The checkout without the requested version must be on the default branch.
+// If the default branch can't be looked up, e.g. offline, then the checkout on any branch is kept.
+func atRef(ctx context.Context, repo *git.Repository, head *plumbing.Reference, dep *Dep, logger *log.Logger) (bool, error) {
+	headHash := head.Hash().String()
+
+	switch {
+	case len(dep.Commit) > 0:
+		return strings.HasPrefix(headHash, strings.ToLower(dep.Commit)), nil
+	case len(dep.Tag) > 0:
+		tagHash, err := repo.ResolveRevision(plumbing.Revision(plumbing.NewTagReferenceName(dep.Tag)))
+		// the missing tag is not fetched yet
+		return err == nil && tagHash.String() == headHash, nil
+	case len(dep.Branch) > 0:
+		return head.Name() == plumbing.NewBranchReferenceName(dep.Branch), nil
+	}
+
+	if !head.Name().IsBranch() {
+		return false, nil
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return false, fmt.Errorf("repo.Remote: %w", err)
+	}
+	branch, err := defaultBranch(ctx, remote)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return false, fmt.Errorf("defaultBranch: %w", ctxErr)
+		}
+		logger.Warn("the default branch is unknown, the checkout is kept", "branch", head.Name().Short(), "error", err)
+		return true, nil
+	}
+
+	return head.Name() == plumbing.NewBranchReferenceName(branch), nil
+}
+
+// checkClean returns ErrSourceDirty if the tracked files other than go.mod and go.sum are changed.
+// The untracked files are kept by the checkout, so they are not the changes.
+func checkClean(worktree *git.Worktree) error {
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("worktree.Status: %w", err)
+	}
+
+	changed := make([]string, 0)
+	for file, fileStatus := range status {
+		if generatedFiles[file] {
+			continue
+		}
+		if fileStatus.Worktree == git.Untracked && fileStatus.Staging == git.Untracked {
+			continue
+		}
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			changed = append(changed, file)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return newError(ErrSourceDirty, fmt.Errorf("uncommitted changes in %s", strings.Join(changed, ", ")))
+	}
+
+	return nil
+}
+
+// switchRef checks out the requested version of the fetched repository.
+// The branch is reset to its remote head, as the checkout is managed by the DepManager.
+func switchRef(ctx context.Context, repo *git.Repository, worktree *git.Worktree, dep *Dep) error {
+	if len(dep.Commit) > 0 || len(dep.Tag) > 0 {
+		revision := dep.Commit
+		if len(revision) == 0 {
+			revision = plumbing.NewTagReferenceName(dep.Tag).String()
+		}
+		hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+		if err != nil {
+			return newError(ErrRefMismatch, fmt.Errorf("repo.ResolveRevision('%s'): %w", revision, err))
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+			return fmt.Errorf("worktree.Checkout('%s'): %w", hash, err)
+		}
+		return nil
+	}
+
+	branch := dep.Branch
+	if len(branch) == 0 {
+		remote, err := repo.Remote(git.DefaultRemoteName)
+		if err != nil {
+			return fmt.Errorf("repo.Remote: %w", err)
+		}
+		branch, err = defaultBranch(ctx, remote)
+		if err != nil {
+			return fmt.Errorf("defaultBranch: %w", err)
+		}
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+	if err != nil {
+		return newError(ErrRefMismatch, fmt.Errorf("no '%s' branch in the remote repository: %w", branch, err))
+	}
+
+	branchName := plumbing.NewBranchReferenceName(branch)
+	_, err = repo.Reference(branchName, false)
+	create := err == plumbing.ErrReferenceNotFound
+	if err != nil && !create {
+		return fmt.Errorf("repo.Reference('%s'): %w", branchName, err)
+	}
+
+	options := &git.CheckoutOptions{Branch: branchName, Create: create, Force: true}
+	if create {
+		options.Hash = remoteRef.Hash()
+	}
+	if err := worktree.Checkout(options); err != nil {
+		return fmt.Errorf("worktree.Checkout('%s'): %w", branchName, err)
+	}
+	if !create {
+		if err := worktree.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+			return fmt.Errorf("worktree.Reset('%s'): %w", remoteRef.Hash(), err)
+		}
+	}
+
+	return nil
+}
+
+// defaultBranch returns the branch the HEAD of the remote repository refers to.
+// If the remote doesn't tell the branch, then the first branch at the HEAD commit is returned.
+func defaultBranch(ctx context.Context, remote *git.Remote) (string, error) {
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return "", fmt.Errorf("remote.List: %w", ctxErr)
+		}
+		return "", fmt.Errorf("remote.List: %w", err)
+	}
+
+	var head *plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+			break
+		}
+	}
+	if head == nil {
+		return "", fmt.Errorf("no HEAD in the remote repository")
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		return head.Target().Short(), nil
+	}
+
+	branches := make([]string, 0)
+	for _, ref := range refs {
+		if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+			branches = append(branches, ref.Name().Short())
+		}
+	}
+	if len(branches) == 0 {
+		return "", fmt.Errorf("the remote HEAD is not a branch")
+	}
+	sort.Strings(branches)
+
+	return branches[0], nil
+}
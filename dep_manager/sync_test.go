@@ -0,0 +1,169 @@
+package dep_manager
+
+import (
+	"context"
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/ahmetson/log-lib"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestSyncSuite struct {
+	suite.Suite
+
+	remotePath string
+	srcPath    string
+	commits    map[string]plumbing.Hash // the commits by the content of the main.go
+	logger     *log.Logger
+}
+
+// SetupTest creates the remote repository, and clones it.
+// The master branch has two commits, the first one is tagged as v1.0.0.
+// The dev branch has one more commit on top of the first one.
+func (test *TestSyncSuite) SetupTest() {
+	s := test.Require
+
+	test.remotePath = test.T().TempDir()
+	repo, err := git.PlainInit(test.remotePath, false)
+	s().NoError(err)
+	worktree, err := repo.Worktree()
+	s().NoError(err)
+
+	test.commits = make(map[string]plumbing.Hash, 3)
+	test.commits["first"] = test.commit(worktree, "first")
+	_, err = repo.CreateTag("v1.0.0", test.commits["first"], &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "v1.0.0",
+	})
+	s().NoError(err)
+	test.commits["second"] = test.commit(worktree, "second")
+
+	s().NoError(worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("dev"),
+		Hash:   test.commits["first"],
+		Create: true,
+	}))
+	test.commits["dev"] = test.commit(worktree, "dev")
+	s().NoError(worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}))
+
+	test.srcPath = test.T().TempDir()
+	_, err = git.PlainClone(test.srcPath, false, &git.CloneOptions{URL: test.remotePath})
+	s().NoError(err)
+
+	logger, err := log.New("TestSyncSuite", false)
+	s().NoError(err)
+	test.logger = logger
+}
+
+// commit writes the content into main.go, and commits it
+func (test *TestSyncSuite) commit(worktree *git.Worktree, content string) plumbing.Hash {
+	s := test.Require
+
+	s().NoError(os.WriteFile(filepath.Join(worktree.Filesystem.Root(), "main.go"), []byte(content), 0644))
+	_, err := worktree.Add("main.go")
+	s().NoError(err)
+	hash, err := worktree.Commit(content, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	s().NoError(err)
+
+	return hash
+}
+
+// content returns the main.go of the checkout
+func (test *TestSyncSuite) content() string {
+	data, err := os.ReadFile(filepath.Join(test.srcPath, "main.go"))
+	test.Require().NoError(err)
+	return string(data)
+}
+
+// Test_10_Switch tests that the checkout is switched to the tag, commit and branch
+func (test *TestSyncSuite) Test_10_Switch() {
+	s := test.Require
+
+	manager := &DepManager{}
+	dep := &Dep{Src: &source.Src{}, srcPath: test.srcPath}
+	ctx := context.Background()
+
+	// the checkout is at the default branch already
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("second", test.content())
+
+	dep.Tag = "v1.0.0"
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("first", test.content())
+	s().NoError(verifyRef(dep))
+
+	dep.Tag = ""
+	dep.Commit = test.commits["dev"].String()[:7]
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("dev", test.content())
+	s().NoError(verifyRef(dep))
+
+	dep.Commit = "ffffffff"
+	s().ErrorIs(manager.syncSrc(ctx, dep, test.logger), ErrRefMismatch)
+
+	dep.Commit = ""
+	dep.Branch = "master"
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("second", test.content())
+
+	dep.Branch = "dev"
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("dev", test.content())
+
+	// the checkout on another branch returns to the default branch
+	dep.Branch = ""
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("second", test.content())
+
+	dep.Branch = "unknown"
+	s().ErrorIs(manager.syncSrc(ctx, dep, test.logger), ErrRefMismatch)
+
+	// the pinned checkout returns to the default branch
+	dep.Branch = ""
+	dep.Tag = "v1.0.0"
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	dep.Tag = ""
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("second", test.content())
+}
+
+// Test_11_Dirty tests that the checkout with the uncommitted changes is not switched
+func (test *TestSyncSuite) Test_11_Dirty() {
+	s := test.Require
+
+	manager := &DepManager{}
+	dep := &Dep{Src: &source.Src{Tag: "v1.0.0"}, srcPath: test.srcPath}
+	ctx := context.Background()
+
+	// the files made by the build are not the changes
+	s().NoError(os.WriteFile(filepath.Join(test.srcPath, "go.sum"), []byte("generated"), 0644))
+	s().NoError(os.WriteFile(filepath.Join(test.srcPath, "notes.txt"), []byte("untracked"), 0644))
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+	s().Equal("first", test.content())
+
+	s().NoError(os.WriteFile(filepath.Join(test.srcPath, "main.go"), []byte("changed"), 0644))
+	dep.Tag = ""
+	s().ErrorIs(manager.syncSrc(ctx, dep, test.logger), ErrSourceDirty)
+	s().Equal("changed", test.content())
+
+	// the source code that is not a git repository is not switched
+	dep.srcPath = test.T().TempDir()
+	s().NoError(manager.syncSrc(ctx, dep, test.logger))
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestSync(t *testing.T) {
+	suite.Run(t, new(TestSyncSuite))
+}
@@ -0,0 +1,171 @@
+package dep_manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/ahmetson/log-lib"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Update fetches the latest commit of the tracked Branch or Tag, and rebuilds the installed dependency.
+// The dependency without the version tracks the default branch, and the dependency pinned to the Commit is rebuilt as is.
+// Unlike Install, the commit in the DepManager.LockFile is ignored, and the new commit is locked after the build.
+//
+// The previous binary is kept, see DepManager.Rollback.
+// If the new build fails, then the installed binary is not changed, and the source code is restored.
+//
+// The local source code is not fetched, it's rebuilt only.
+// Returns ErrNotInstalled if there is no binary to update,
+// and ErrSourceDirty if the source code has the uncommitted changes.
+func (manager *DepManager) Update(dep *Dep, parent *log.Logger) error {
+	return manager.UpdateContext(context.Background(), dep, parent)
+}
+
+// UpdateContext is the Update that stops the fetch and build when the context is done.
+// The timeouts are the same as in InstallContext.
+func (manager *DepManager) UpdateContext(ctx context.Context, dep *Dep, parent *log.Logger) error {
+	if manager == nil || ctx == nil || dep == nil || parent == nil {
+		return fmt.Errorf("nil")
+	}
+
+	if !dep.IsLinted() {
+		return fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
+	}
+
+	if !dep.manageableBin {
+		return fmt.Errorf("can not update: %w", ErrNotManageable)
+	}
+
+	unlock := manager.lockPaths(dep.srcPath, dep.binPath)
+	defer unlock()
+
+	if !manager.Installed(dep) {
+		return fmt.Errorf("%w. Call DepManager.Install(Dep, log.Logger) first", ErrNotInstalled)
+	}
+
+	return manager.update(ctx, dep, parent)
+}
+
+// The update fetches the source code, and rebuilds the binary.
+// The caller must lock the source code and binary paths.
+func (manager *DepManager) update(ctx context.Context, dep *Dep, parent *log.Logger) error {
+	logger := parent.Child("update", "srcUrl", dep.Url)
+	cloneTimeout, buildTimeout := manager.installTimeouts()
+
+	srcExist, err := manager.srcExist(dep)
+	if err != nil {
+		return fmt.Errorf("dep_manager.srcExist(%s): %w", dep.Url, err)
+	}
+
+	// the head before the update, nil if there is nothing to restore
+	var previous *plumbing.Reference
+	if !srcExist {
+		if !dep.manageableSrc {
+			return fmt.Errorf("%w at '%s' path. and it's not manageable by DepManager", ErrSourceMissing, dep.srcPath)
+		}
+		if err := dep.stage(StageCloning); err != nil {
+			return fmt.Errorf("dep.stage('%s'): %w", StageCloning, err)
+		}
+		cloneCtx, cancel := withTimeout(ctx, cloneTimeout)
+		err = manager.downloadSrc(cloneCtx, dep, logger)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("downloadSrc: %w", err)
+		}
+	} else if dep.manageableSrc {
+		fetchCtx, cancel := withTimeout(ctx, cloneTimeout)
+		previous, err = manager.updateSrc(fetchCtx, dep, logger)
+		cancel()
+		if err != nil {
+			manager.restoreSrc(dep, previous, logger)
+			return fmt.Errorf("updateSrc: %w", err)
+		}
+	}
+
+	if err := verifyRef(dep); err != nil {
+		manager.restoreSrc(dep, previous, logger)
+		return fmt.Errorf("verifyRef: %w", err)
+	}
+
+	buildCtx, cancel := withTimeout(ctx, buildTimeout)
+	defer cancel()
+	err = manager.build(buildCtx, dep, logger)
+	if err != nil {
+		manager.restoreSrc(dep, previous, logger)
+		return fmt.Errorf("build: %w", err)
+	}
+
+	if err := manager.lock(dep, manager.buildFlags(dep)); err != nil {
+		return fmt.Errorf("manager.lock: %w", err)
+	}
+
+	return nil
+}
+
+// updateSrc switches the source code to the latest commit of its version in the remote repository.
+// Returns the head before the update, or nil if the source code is not a git repository.
+func (manager *DepManager) updateSrc(ctx context.Context, dep *Dep, logger *log.Logger) (*plumbing.Reference, error) {
+	repo, err := git.PlainOpen(dep.srcPath)
+	if err == git.ErrRepositoryNotExists {
+		// the source code was not cloned by the DepManager
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git.PlainOpen('%s'): %w", dep.srcPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("repo.Head: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("repo.Worktree: %w", err)
+	}
+	if err := checkClean(worktree); err != nil {
+		return nil, fmt.Errorf("checkClean('%s'): %w", dep.srcPath, err)
+	}
+
+	logger.Info("updating the source code", "from", head.Hash().String(), "version", dep.Ref().String())
+	if err := fetchSrc(ctx, repo, dep, logger); err != nil {
+		return head, fmt.Errorf("fetchSrc: %w", err)
+	}
+	if err := switchRef(ctx, repo, worktree, dep); err != nil {
+		return head, fmt.Errorf("switchRef: %w", err)
+	}
+
+	return head, nil
+}
+
+// restoreSrc checks out the head that the source code had before the update.
+// The branch is reset to its previous commit.
+// The failure is logged only, as the next installation switches the source code anyway.
+func (manager *DepManager) restoreSrc(dep *Dep, previous *plumbing.Reference, logger *log.Logger) {
+	if previous == nil {
+		return
+	}
+
+	repo, err := git.PlainOpen(dep.srcPath)
+	if err != nil {
+		logger.Warn("failed to restore the source code", "srcPath", dep.srcPath, "error", err)
+		return
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		logger.Warn("failed to restore the source code", "srcPath", dep.srcPath, "error", err)
+		return
+	}
+
+	options := &git.CheckoutOptions{Hash: previous.Hash(), Force: true}
+	if previous.Name().IsBranch() {
+		options = &git.CheckoutOptions{Branch: previous.Name(), Force: true}
+	}
+	err = worktree.Checkout(options)
+	if err == nil && previous.Name().IsBranch() {
+		err = worktree.Reset(&git.ResetOptions{Commit: previous.Hash(), Mode: git.HardReset})
+	}
+	if err != nil {
+		logger.Warn("failed to restore the source code", "srcPath", dep.srcPath, "error", err)
+	}
+}
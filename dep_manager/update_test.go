@@ -0,0 +1,122 @@
+package dep_manager
+
+import (
+	"github.com/ahmetson/log-lib"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestUpdateSuite struct {
+	suite.Suite
+
+	logger     *log.Logger
+	depManager *DepManager
+	remote     *git.Worktree // the remote repository of the dependency
+	dep        *Dep
+}
+
+// SetupTest creates the remote repository of the buildable dependency, and clones it into the DepManager.Src
+func (test *TestUpdateSuite) SetupTest() {
+	s := test.Require
+
+	logger, err := log.New("TestUpdateSuite", false)
+	s().NoError(err)
+	test.logger = logger
+
+	root := test.T().TempDir()
+	test.depManager = New()
+	s().NoError(test.depManager.SetPaths(filepath.Join(root, "src"), filepath.Join(root, "bin")))
+
+	remotePath := filepath.Join(root, "remote")
+	repo, err := git.PlainInit(remotePath, false)
+	s().NoError(err)
+	test.remote, err = repo.Worktree()
+	s().NoError(err)
+	s().NoError(os.WriteFile(filepath.Join(remotePath, "go.mod"), []byte("module example.com/app\n\ngo 1.19\n"), 0644))
+	test.commit("package main\n\nfunc main() {}\n")
+
+	dep, err := NewDep("example.com/app", "", "")
+	s().NoError(err)
+	test.depManager.Lint(dep)
+	test.dep = dep
+
+	_, err = git.PlainClone(dep.srcPath, false, &git.CloneOptions{URL: remotePath})
+	s().NoError(err)
+}
+
+// commit writes the main.go into the remote repository, and commits it
+func (test *TestUpdateSuite) commit(content string) plumbing.Hash {
+	s := test.Require
+
+	root := test.remote.Filesystem.Root()
+	s().NoError(os.WriteFile(filepath.Join(root, "main.go"), []byte(content), 0644))
+	_, err := test.remote.Add(".")
+	s().NoError(err)
+	hash, err := test.remote.Commit(content, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	s().NoError(err)
+
+	return hash
+}
+
+// head returns the commit of the dependency source code
+func (test *TestUpdateSuite) head() plumbing.Hash {
+	repo, err := git.PlainOpen(test.dep.srcPath)
+	test.Require().NoError(err)
+	head, err := repo.Head()
+	test.Require().NoError(err)
+	return head.Hash()
+}
+
+// Test_10_Update tests that the dependency is rebuilt at the latest commit,
+// and the installed binary is kept if the build fails
+func (test *TestUpdateSuite) Test_10_Update() {
+	s := test.Require
+
+	// nothing to update
+	s().ErrorIs(test.depManager.Update(test.dep, test.logger), ErrNotInstalled)
+
+	s().NoError(test.depManager.Install(test.dep, test.logger))
+	installedCommit := test.head()
+	installed, err := fileChecksum(test.dep.binPath)
+	s().NoError(err)
+
+	latest := test.commit("package main\n\nfunc main() { println(\"updated\") }\n")
+	s().NoError(test.depManager.Update(test.dep, test.logger))
+	s().Equal(latest, test.head())
+
+	updated, err := fileChecksum(test.dep.binPath)
+	s().NoError(err)
+	s().NotEqual(installed, updated)
+	previous, err := fileChecksum(versionPath(test.dep.binPath, installedCommit.String()))
+	s().NoError(err)
+	s().Equal(installed, previous)
+
+	// the invalid code is not built, so the updated binary is kept, and its source code is restored
+	test.commit("package main\n\nfunc main() {\n")
+	s().ErrorIs(test.depManager.Update(test.dep, test.logger), ErrBuildFailed)
+	s().Equal(latest, test.head())
+	restored, err := fileChecksum(test.dep.binPath)
+	s().NoError(err)
+	s().Equal(updated, restored)
+
+	// the changed source code is not updated
+	s().NoError(os.WriteFile(filepath.Join(test.dep.srcPath, "main.go"), []byte("changed"), 0644))
+	s().ErrorIs(test.depManager.Update(test.dep, test.logger), ErrSourceDirty)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestUpdate(t *testing.T) {
+	suite.Run(t, new(TestUpdateSuite))
+}
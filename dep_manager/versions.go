@@ -0,0 +1,296 @@
+package dep_manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultKeepBins is the amount of the built binaries kept for each dependency, including the installed one
+const DefaultKeepBins = 3
+
+const (
+	versionSeparator = "@"      // separates the binary name and the commit of the kept binary
+	buildSuffix      = ".build" // the binary being built
+	replaceSuffix    = ".tmp"   // the binary being installed
+)
+
+// binCommitPattern is the full commit hash in the name of the kept binary
+var binCommitPattern = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// A BinVersion is the binary built from the commit, kept for DepManager.Rollback.
+// Its path is the binary path with '@<commit>' before the extension.
+type BinVersion struct {
+	Commit  string    `json:"commit"`
+	Path    string    `json:"path"`
+	Time    time.Time `json:"time"`    // when the binary was built
+	Current bool      `json:"current"` // the binary is the installed one
+}
+
+// SetKeepBins sets the amount of the built binaries kept for each dependency, including the installed one.
+// The older binaries are deleted after the next build.
+func (manager *DepManager) SetKeepBins(keepBins int) error {
+	if keepBins < 1 {
+		return fmt.Errorf("keep bins must be positive")
+	}
+
+	manager.mu.Lock()
+	manager.keepBins = keepBins
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// BinVersions returns the kept binaries of the dependency, the newest first
+func (manager *DepManager) BinVersions(dep *Dep) ([]*BinVersion, error) {
+	if manager == nil || dep == nil {
+		return nil, fmt.Errorf("nil")
+	}
+	if !dep.IsLinted() {
+		return nil, fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
+	}
+
+	return binVersions(dep.binPath)
+}
+
+// Rollback installs the binary kept before the installed one.
+// Each call goes one version back. The source code is not changed,
+// but the rolled back commit is locked in the DepManager.LockFile, so the next Install builds it.
+//
+// Returns ErrNoPrevious if there is no older binary.
+func (manager *DepManager) Rollback(dep *Dep) (*BinVersion, error) {
+	if manager == nil || dep == nil {
+		return nil, fmt.Errorf("nil")
+	}
+
+	if !dep.IsLinted() {
+		return nil, fmt.Errorf("%w. Call DepManager.Lint(Dep) first", ErrNotLinted)
+	}
+
+	if !dep.manageableBin {
+		return nil, fmt.Errorf("can not rollback: %w", ErrNotManageable)
+	}
+
+	unlock := manager.lockPaths(dep.srcPath, dep.binPath)
+	defer unlock()
+
+	versions, err := binVersions(dep.binPath)
+	if err != nil {
+		return nil, fmt.Errorf("binVersions('%s'): %w", dep.binPath, err)
+	}
+
+	// the unknown binary is replaced by the newest one
+	next := 0
+	for i, version := range versions {
+		if version.Current {
+			next = i + 1
+			break
+		}
+	}
+	if next >= len(versions) {
+		return nil, fmt.Errorf("the '%s' %w", dep.Url, ErrNoPrevious)
+	}
+	version := versions[next]
+
+	if err := replaceBin(version.Path, dep.binPath); err != nil {
+		return nil, fmt.Errorf("replaceBin('%s'): %w", version.Path, err)
+	}
+	if err := manager.lockCommit(dep, version.Commit, manager.buildFlags(dep)); err != nil {
+		return nil, fmt.Errorf("manager.lockCommit('%s'): %w", version.Commit, err)
+	}
+	version.Current = true
+
+	return version, nil
+}
+
+// installBin keeps the built binary as the version of the commit, and installs it.
+// The binary built from the source code without the commit is installed as is.
+// The versions beyond DepManager.SetKeepBins are deleted.
+func (manager *DepManager) installBin(dep *Dep, builtPath string, commit string) error {
+	if len(commit) == 0 {
+		if err := os.Rename(builtPath, dep.binPath); err != nil {
+			return fmt.Errorf("os.Rename('%s', '%s'): %w", builtPath, dep.binPath, err)
+		}
+		return nil
+	}
+
+	version := versionPath(dep.binPath, commit)
+	if err := os.Rename(builtPath, version); err != nil {
+		return fmt.Errorf("os.Rename('%s', '%s'): %w", builtPath, version, err)
+	}
+	if err := replaceBin(version, dep.binPath); err != nil {
+		return fmt.Errorf("replaceBin('%s'): %w", version, err)
+	}
+
+	manager.mu.RLock()
+	keepBins := manager.keepBins
+	manager.mu.RUnlock()
+	if err := pruneBins(dep.binPath, keepBins); err != nil {
+		return fmt.Errorf("pruneBins('%s'): %w", dep.binPath, err)
+	}
+
+	return nil
+}
+
+// deleteBinVersions deletes the kept binaries of the dependency
+func deleteBinVersions(binPath string) error {
+	versions, err := binVersions(binPath)
+	if err != nil {
+		return fmt.Errorf("binVersions: %w", err)
+	}
+	for _, version := range versions {
+		if err := os.Remove(version.Path); err != nil {
+			return fmt.Errorf("os.Remove('%s'): %w", version.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// pruneBins deletes the oldest binaries, so only keepBins are left.
+// The installed binary is never deleted. If keepBins is 0, then the DefaultKeepBins is used.
+func pruneBins(binPath string, keepBins int) error {
+	if keepBins <= 0 {
+		keepBins = DefaultKeepBins
+	}
+
+	versions, err := binVersions(binPath)
+	if err != nil {
+		return fmt.Errorf("binVersions: %w", err)
+	}
+	for i := keepBins; i < len(versions); i++ {
+		if versions[i].Current {
+			continue
+		}
+		if err := os.Remove(versions[i].Path); err != nil {
+			return fmt.Errorf("os.Remove('%s'): %w", versions[i].Path, err)
+		}
+	}
+
+	return nil
+}
+
+// replaceBin installs the binary atomically, so the binary path is never missing or partially written.
+// The binary is linked if possible, otherwise it's copied.
+func replaceBin(srcPath string, binPath string) error {
+	tmpPath := binPath + replaceSuffix
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("os.Remove('%s'): %w", tmpPath, err)
+	}
+	if err := os.Link(srcPath, tmpPath); err != nil {
+		if err := copyBin(srcPath, tmpPath); err != nil {
+			_ = os.Remove(tmpPath)
+			return fmt.Errorf("copyBin: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, binPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("os.Rename('%s', '%s'): %w", tmpPath, binPath, err)
+	}
+
+	return nil
+}
+
+// copyBin copies the executable file
+func copyBin(srcPath string, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("os.Open: %w", err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+
+	return dst.Close()
+}
+
+// binVersions returns the kept binaries, the newest first.
+// The installed binary is found by its checksum, as it may be a copy.
+func binVersions(binPath string) ([]*BinVersion, error) {
+	dir := filepath.Dir(binPath)
+	name, ext := binName(binPath)
+	prefix := name + versionSeparator
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*BinVersion{}, nil
+		}
+		return nil, fmt.Errorf("os.ReadDir('%s'): %w", dir, err)
+	}
+
+	current, err := fileChecksum(binPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("fileChecksum('%s'): %w", binPath, err)
+	}
+
+	versions := make([]*BinVersion, 0)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(fileName, prefix) || !strings.HasSuffix(fileName, ext) {
+			continue
+		}
+		commit := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), ext)
+		if !binCommitPattern.MatchString(commit) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("entry.Info('%s'): %w", fileName, err)
+		}
+		versions = append(versions, &BinVersion{
+			Commit: commit,
+			Path:   filepath.Join(dir, fileName),
+			Time:   info.ModTime(),
+		})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+
+	if len(current) > 0 {
+		for _, version := range versions {
+			checksum, err := fileChecksum(version.Path)
+			if err != nil {
+				return nil, fmt.Errorf("fileChecksum('%s'): %w", version.Path, err)
+			}
+			if checksum == current {
+				version.Current = true
+				break
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// versionPath returns the path of the binary built from the commit
+func versionPath(binPath string, commit string) string {
+	name, ext := binName(binPath)
+	return filepath.Join(filepath.Dir(binPath), name+versionSeparator+commit+ext)
+}
+
+// binName returns the file name of the binary without the executable extension, and the extension.
+// The binary name has the dots of the url, so only '.exe' is the extension.
+func binName(binPath string) (string, string) {
+	fileName := filepath.Base(binPath)
+	if strings.HasSuffix(fileName, ".exe") {
+		return strings.TrimSuffix(fileName, ".exe"), ".exe"
+	}
+	return fileName, ""
+}
@@ -0,0 +1,128 @@
+package dep_manager
+
+import (
+	"github.com/ahmetson/dev-lib/source"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestVersionsSuite struct {
+	suite.Suite
+
+	depManager *DepManager
+	dep        *Dep
+	commits    []string // the commits of the kept binaries, the oldest first
+}
+
+// SetupTest keeps three binaries, the newest one is installed
+func (test *TestVersionsSuite) SetupTest() {
+	s := test.Require
+
+	binDir := test.T().TempDir()
+	test.depManager = New()
+	test.dep = &Dep{
+		Src:           &source.Src{Url: "github.com/ahmetson/test-manager"},
+		srcPath:       test.T().TempDir(),
+		binPath:       filepath.Join(binDir, "github.com.ahmetson.test-manager"),
+		manageableBin: true,
+	}
+
+	test.commits = []string{strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40)}
+	built := time.Now().Add(-time.Hour)
+	for _, commit := range test.commits {
+		version := versionPath(test.dep.binPath, commit)
+		s().NoError(os.WriteFile(version, []byte(commit), 0755))
+		s().NoError(os.Chtimes(version, built, built))
+		built = built.Add(time.Minute)
+	}
+	s().NoError(replaceBin(versionPath(test.dep.binPath, test.commits[2]), test.dep.binPath))
+}
+
+// content returns the installed binary
+func (test *TestVersionsSuite) content() string {
+	data, err := os.ReadFile(test.dep.binPath)
+	test.Require().NoError(err)
+	return string(data)
+}
+
+// Test_10_BinVersions tests that the kept binaries are listed the newest first
+func (test *TestVersionsSuite) Test_10_BinVersions() {
+	s := test.Require
+
+	s().Equal(filepath.Join(filepath.Dir(test.dep.binPath), "app@"+test.commits[0]+".exe"),
+		versionPath(filepath.Join(filepath.Dir(test.dep.binPath), "app.exe"), test.commits[0]))
+
+	// not the version of this binary
+	s().NoError(os.WriteFile(test.dep.binPath+versionSeparator+"latest", []byte("latest"), 0755))
+	s().NoError(os.WriteFile(test.dep.binPath+replaceSuffix, []byte("tmp"), 0755))
+
+	versions, err := test.depManager.BinVersions(test.dep)
+	s().NoError(err)
+	s().Len(versions, 3)
+	s().Equal(test.commits[2], versions[0].Commit)
+	s().True(versions[0].Current)
+	s().Equal(test.commits[0], versions[2].Commit)
+	s().False(versions[2].Current)
+}
+
+// Test_11_Prune tests that the oldest binaries are deleted except the installed one
+func (test *TestVersionsSuite) Test_11_Prune() {
+	s := test.Require
+
+	s().NoError(pruneBins(test.dep.binPath, 2))
+	versions, err := binVersions(test.dep.binPath)
+	s().NoError(err)
+	s().Len(versions, 2)
+	s().Equal(test.commits[1], versions[1].Commit)
+
+	// the installed binary is kept, even if it's the oldest one
+	s().NoError(replaceBin(versions[1].Path, test.dep.binPath))
+	s().NoError(pruneBins(test.dep.binPath, 1))
+	versions, err = binVersions(test.dep.binPath)
+	s().NoError(err)
+	s().Len(versions, 2)
+
+	s().NoError(deleteBinVersions(test.dep.binPath))
+	versions, err = binVersions(test.dep.binPath)
+	s().NoError(err)
+	s().Empty(versions)
+	s().Equal(test.commits[1], test.content())
+}
+
+// Test_12_Rollback tests that each rollback installs the older binary
+func (test *TestVersionsSuite) Test_12_Rollback() {
+	s := test.Require
+
+	version, err := test.depManager.Rollback(test.dep)
+	s().NoError(err)
+	s().Equal(test.commits[1], version.Commit)
+	s().Equal(test.commits[1], test.content())
+
+	version, err = test.depManager.Rollback(test.dep)
+	s().NoError(err)
+	s().Equal(test.commits[0], version.Commit)
+	s().Equal(test.commits[0], test.content())
+
+	_, err = test.depManager.Rollback(test.dep)
+	s().ErrorIs(err, ErrNoPrevious)
+	s().Equal(test.commits[0], test.content())
+
+	// the versions are not reordered by the rollback
+	versions, err := test.depManager.BinVersions(test.dep)
+	s().NoError(err)
+	s().Equal(test.commits[2], versions[0].Commit)
+	s().True(versions[2].Current)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestVersions(t *testing.T) {
+	suite.Run(t, new(TestVersionsSuite))
+}
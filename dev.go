@@ -12,6 +12,7 @@ import (
 	"github.com/ahmetson/dev-lib/proxy_handler"
 	"github.com/ahmetson/handler-lib/manager_client"
 	"github.com/ahmetson/log-lib"
+	"time"
 )
 
 // A Context handles the config of the contexts
@@ -180,6 +181,50 @@ func (ctx *Context) StartDepManager() error {
 	if err != nil {
 		return fmt.Errorf("configClient.String(%s): %w", SrcKey, err)
 	}
+	statePath, err := ctx.configClient.String(StateKey)
+	if err != nil {
+		return fmt.Errorf("configClient.String(%s): %w", StateKey, err)
+	}
+	logsPath, err := ctx.configClient.String(LogsKey)
+	if err != nil {
+		return fmt.Errorf("configClient.String(%s): %w", LogsKey, err)
+	}
+	lockPath, err := ctx.configClient.String(LockKey)
+	if err != nil {
+		return fmt.Errorf("configClient.String(%s): %w", LockKey, err)
+	}
+	logMaxSize, err := ctx.configClient.Uint64(LogMaxSizeKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", LogMaxSizeKey, err)
+	}
+	logMaxFiles, err := ctx.configClient.Uint64(LogMaxFilesKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", LogMaxFilesKey, err)
+	}
+	keepBins, err := ctx.configClient.Uint64(KeepBinsKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", KeepBinsKey, err)
+	}
+	cloneTimeout, err := ctx.configClient.Uint64(CloneTimeoutKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", CloneTimeoutKey, err)
+	}
+	buildTimeout, err := ctx.configClient.Uint64(BuildTimeoutKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", BuildTimeoutKey, err)
+	}
+	closeGrace, err := ctx.configClient.Uint64(CloseGraceKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", CloseGraceKey, err)
+	}
+	termGrace, err := ctx.configClient.Uint64(TermGraceKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", TermGraceKey, err)
+	}
+	healthInterval, err := ctx.configClient.Uint64(HealthIntervalKey)
+	if err != nil {
+		return fmt.Errorf("configClient.Uint64(%s): %w", HealthIntervalKey, err)
+	}
 
 	//
 	// Start the dependency manager
@@ -188,6 +233,43 @@ func (ctx *Context) StartDepManager() error {
 	if err := depManager.SetPaths(binPath, srcPath); err != nil {
 		return fmt.Errorf("depManager.SetPaths('%s', '%s'): %w", binPath, srcPath, err)
 	}
+	if err := depManager.SetStatePath(statePath); err != nil {
+		return fmt.Errorf("depManager.SetStatePath('%s'): %w", statePath, err)
+	}
+	if err := depManager.SetLogPath(logsPath); err != nil {
+		return fmt.Errorf("depManager.SetLogPath('%s'): %w", logsPath, err)
+	}
+	if err := depManager.SetLockPath(lockPath); err != nil {
+		return fmt.Errorf("depManager.SetLockPath('%s'): %w", lockPath, err)
+	}
+	if err := depManager.SetLogRotation(int64(logMaxSize), int(logMaxFiles)); err != nil {
+		return fmt.Errorf("depManager.SetLogRotation(%d, %d): %w", logMaxSize, logMaxFiles, err)
+	}
+	if err := depManager.SetKeepBins(int(keepBins)); err != nil {
+		return fmt.Errorf("depManager.SetKeepBins(%d): %w", keepBins, err)
+	}
+	depManager.SetInstallTimeouts(time.Duration(cloneTimeout)*time.Second, time.Duration(buildTimeout)*time.Second)
+	depManager.SetGracePeriods(time.Duration(closeGrace)*time.Second, time.Duration(termGrace)*time.Second)
+	if healthInterval > 0 {
+		healthPolicy := dep_manager.NewHealthPolicy()
+		healthPolicy.Interval = time.Duration(healthInterval) * time.Second
+		if err := depManager.StartHealthMonitor(healthPolicy); err != nil {
+			return fmt.Errorf("depManager.StartHealthMonitor: %w", err)
+		}
+	}
+	// the dependencies spawned by the previous run of the service
+	report, err := depManager.Reconcile()
+	if err != nil {
+		return fmt.Errorf("depManager.Reconcile: %w", err)
+	}
+	if !report.IsEmpty() {
+		logger, err := log.New("dep-manager", true)
+		if err != nil {
+			return fmt.Errorf("log.New('dep-manager'): %w", err)
+		}
+		logger.Info("reconciled the previous dependencies",
+			"adopted", report.Adopted, "killed", report.Killed, "removed", report.Removed)
+	}
 	ctx.depHandler, err = dep_handler.New(depManager)
 	if err != nil {
 		return fmt.Errorf("dep_handler.New: %w", err)
@@ -0,0 +1,231 @@
+package proxy_client
+
+import (
+	"fmt"
+	"github.com/ahmetson/config-lib/service"
+	"sync"
+)
+
+// The names of the Fake methods that could be scripted to fail with Fake.Fail.
+const (
+	SetMethod                 = "Set"
+	SetUnitsMethod            = "SetUnits"
+	ProxyChainsByLastIdMethod = "ProxyChainsByLastId"
+	UnitsMethod               = "Units"
+	LastProxiesMethod         = "LastProxies"
+	StartLastProxiesMethod    = "StartLastProxies"
+	ProxyChainByRuleMethod    = "ProxyChainByRule"
+	ProxyChainsMethod         = "ProxyChains"
+)
+
+// A Call is the recorded invocation of the Fake client
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+type fakeUnits struct {
+	rule  *service.Rule
+	units []*service.Unit
+}
+
+// Fake is the in-process proxy client that keeps the proxy chains in the memory.
+// It doesn't start any proxy.
+// The calls are recorded, and any method could be scripted to fail.
+//
+// Intended to be used by the test context.
+type Fake struct {
+	mu          sync.Mutex
+	calls       []*Call
+	failures    map[string]error
+	proxyChains []*service.ProxyChain
+	proxyUnits  []*fakeUnits
+}
+
+// NewFake returns a proxy client without proxy chains
+func NewFake() *Fake {
+	return &Fake{
+		calls:       make([]*Call, 0),
+		failures:    make(map[string]error),
+		proxyChains: make([]*service.ProxyChain, 0),
+		proxyUnits:  make([]*fakeUnits, 0),
+	}
+}
+
+// Fail makes the method to return the given error.
+// Pass nil error to make the method succeed again.
+func (f *Fake) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// Calls returns the recorded calls.
+// If the methods are given, then returns the calls of these methods only.
+func (f *Fake) Calls(methods ...string) []*Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]*Call, 0, len(f.calls))
+	for _, call := range f.calls {
+		if len(methods) == 0 {
+			calls = append(calls, call)
+			continue
+		}
+		for _, method := range methods {
+			if call.Method == method {
+				calls = append(calls, call)
+				break
+			}
+		}
+	}
+
+	return calls
+}
+
+// record the call and return the scripted error.
+// The caller must lock the mutex.
+func (f *Fake) record(method string, args ...interface{}) error {
+	f.calls = append(f.calls, &Call{Method: method, Args: args})
+	return f.failures[method]
+}
+
+// Set adds the proxy chain or over-writes the proxy chain with the same destination
+func (f *Fake) Set(proxyChain *service.ProxyChain) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetMethod, proxyChain); err != nil {
+		return err
+	}
+	if proxyChain == nil || !proxyChain.IsValid() {
+		return fmt.Errorf("proxy chain is not valid")
+	}
+
+	for i := range f.proxyChains {
+		if service.IsEqualRule(f.proxyChains[i].Destination, proxyChain.Destination) {
+			f.proxyChains[i] = proxyChain
+			return nil
+		}
+	}
+	f.proxyChains = append(f.proxyChains, proxyChain)
+
+	return nil
+}
+
+// SetUnits sets or over-writes the units of the rule
+func (f *Fake) SetUnits(rule *service.Rule, units []*service.Unit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(SetUnitsMethod, rule, units); err != nil {
+		return err
+	}
+	if rule == nil || !rule.IsValid() {
+		return fmt.Errorf("the 'rule' parameter is not valid")
+	}
+
+	for _, ruleUnits := range f.proxyUnits {
+		if service.IsEqualRule(ruleUnits.rule, rule) {
+			ruleUnits.units = units
+			return nil
+		}
+	}
+	f.proxyUnits = append(f.proxyUnits, &fakeUnits{rule: rule, units: units})
+
+	return nil
+}
+
+// ProxyChainsByLastId returns the proxy chains where the last proxy has the given id
+func (f *Fake) ProxyChainsByLastId(id string) ([]*service.ProxyChain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(ProxyChainsByLastIdMethod, id); err != nil {
+		return nil, err
+	}
+
+	proxyChains := make([]*service.ProxyChain, 0, len(f.proxyChains))
+	for _, proxyChain := range f.proxyChains {
+		lastProxy := len(proxyChain.Proxies) - 1
+		if lastProxy == -1 {
+			continue
+		}
+		if proxyChain.Proxies[lastProxy].Id == id {
+			proxyChains = append(proxyChains, proxyChain)
+		}
+	}
+
+	return proxyChains, nil
+}
+
+// Units returns the destination units of the rule
+func (f *Fake) Units(rule *service.Rule) ([]*service.Unit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(UnitsMethod, rule); err != nil {
+		return nil, err
+	}
+
+	for _, ruleUnits := range f.proxyUnits {
+		if service.IsEqualRule(ruleUnits.rule, rule) {
+			return ruleUnits.units, nil
+		}
+	}
+
+	return []*service.Unit{}, nil
+}
+
+// LastProxies returns the last proxies of the proxy chains
+func (f *Fake) LastProxies() ([]*service.Proxy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(LastProxiesMethod); err != nil {
+		return nil, err
+	}
+
+	return service.LastProxies(f.proxyChains), nil
+}
+
+// StartLastProxies doesn't start anything, only records the call
+func (f *Fake) StartLastProxies() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.record(StartLastProxiesMethod)
+}
+
+// ProxyChainByRule returns the proxy chain by the destination.
+// Returns nil if not found.
+func (f *Fake) ProxyChainByRule(rule *service.Rule) (*service.ProxyChain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(ProxyChainByRuleMethod, rule); err != nil {
+		return nil, err
+	}
+
+	return service.ProxyChainByRule(f.proxyChains, rule), nil
+}
+
+// ProxyChains returns all proxy chains
+func (f *Fake) ProxyChains() ([]*service.ProxyChain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record(ProxyChainsMethod); err != nil {
+		return nil, err
+	}
+
+	proxyChains := make([]*service.ProxyChain, len(f.proxyChains))
+	copy(proxyChains, f.proxyChains)
+
+	return proxyChains, nil
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/ahmetson/datatype-lib/data_type/key_value"
 	"github.com/ahmetson/datatype-lib/message"
 	"github.com/ahmetson/dev-lib/dep_client"
+	"github.com/ahmetson/dev-lib/dep_handler"
+	"github.com/ahmetson/dev-lib/dep_manager"
 	"github.com/ahmetson/handler-lib/base"
 	handlerConfig "github.com/ahmetson/handler-lib/config"
 	"slices"
+	"time"
 )
 
 const (
@@ -30,6 +33,9 @@ const (
 	ProxyChains         = "proxy-chains"            // returns list of proxy chains
 )
 
+// ReadyTimeout is how long the started proxy waits for the heartbeat before the proxies that send the messages to it start
+const ReadyTimeout = time.Second * 5
+
 type ProxyHandler struct {
 	*base.Handler
 	proxyChains []*service.ProxyChain
@@ -284,7 +290,10 @@ func (proxyHandler *ProxyHandler) onLastProxies(req message.RequestInterface) me
 	return req.Ok(params)
 }
 
-// onStartLastProxies starts the proxies
+// onStartLastProxies starts the proxies.
+// The proxy that is before another proxy in the proxy chain starts after it.
+// If the socket of that proxy is known, then the proxy starts after that proxy replies to the heartbeat, see ReadyTimeout.
+// The order is set as the graph of the dependency manager, see dep_client.Interface.Graph.
 func (proxyHandler *ProxyHandler) onStartLastProxies(req message.RequestInterface) message.ReplyInterface {
 	if len(proxyHandler.serviceId) == 0 {
 		return req.Fail("serviceId not set. call ProxyHandler.SetServiceId first")
@@ -308,7 +317,9 @@ func (proxyHandler *ProxyHandler) onStartLastProxies(req message.RequestInterfac
 		return req.Fail(fmt.Sprintf("engine.Service('%s'): %v", proxyHandler.serviceId, err))
 	}
 
-	// todo make sure to run in concurrency to run multiple proxies on parallel
+	// the proxies to start, the running ones are not in the graph
+	startProxies := make([]*service.Proxy, 0, len(proxies))
+	deps := make([]*dep_handler.GraphDep, 0, len(proxies))
 	for i := range proxies {
 		proxy := proxies[i]
 
@@ -329,10 +340,14 @@ func (proxyHandler *ProxyHandler) onStartLastProxies(req message.RequestInterfac
 				continue
 			}
 
-			if err := depManager.Run(proxy.Url, proxy.Id, serviceConfig.Manager, proxy.LocalBin); err != nil {
-				return req.Fail(fmt.Sprintf("depManager.Run('%s', '%s'): %v", proxy.Url, proxy.Id, err))
-			}
-
+			startProxies = append(startProxies, proxy)
+			deps = append(deps, &dep_handler.GraphDep{
+				Id:           proxy.Id,
+				Url:          proxy.Url,
+				LocalBin:     proxy.LocalBin,
+				Manager:      proxySource.Manager,
+				ReadyTimeout: uint64(ReadyTimeout.Milliseconds()),
+			})
 			continue
 		}
 
@@ -348,14 +363,53 @@ func (proxyHandler *ProxyHandler) onStartLastProxies(req message.RequestInterfac
 			}
 		}
 
-		if err := depManager.Run(proxy.Url, proxy.Id, serviceConfig.Manager, proxy.LocalBin); err != nil {
-			return req.Fail(fmt.Sprintf("depManager.Run('%s', '%s'): %v", proxy.Url, proxy.Id, err))
-		}
+		// the socket of the new proxy is not known, so it's not waited for
+		startProxies = append(startProxies, proxy)
+		deps = append(deps, &dep_handler.GraphDep{Id: proxy.Id, Url: proxy.Url, LocalBin: proxy.LocalBin})
+	}
+
+	if len(deps) == 0 {
+		return req.Ok(key_value.New())
+	}
+
+	if err := depManager.SetGraph(proxyGraph(proxyHandler.proxyChains, startProxies)); err != nil {
+		return req.Fail(fmt.Sprintf("depManager.SetGraph: %v", err))
+	}
+
+	if err := depManager.RunGraph(deps, serviceConfig.Manager); err != nil {
+		return req.Fail(fmt.Sprintf("depManager.RunGraph: %v", err))
 	}
 
 	return req.Ok(key_value.New())
 }
 
+// proxyGraph returns the order of the proxies to start.
+// The proxy requires the next proxy to start in the proxy chain, as it sends the messages there.
+func proxyGraph(proxyChains []*service.ProxyChain, proxies []*service.Proxy) *dep_manager.Graph {
+	ids := make(map[string]bool, len(proxies))
+	graph := dep_manager.NewGraph()
+	for _, proxy := range proxies {
+		ids[proxy.Id] = true
+		graph.Require(proxy.Id)
+	}
+
+	for _, proxyChain := range proxyChains {
+		next := ""
+		for i := len(proxyChain.Proxies) - 1; i >= 0; i-- {
+			id := proxyChain.Proxies[i].Id
+			if !ids[id] || id == next {
+				continue
+			}
+			if len(next) > 0 {
+				graph.Require(id, next)
+			}
+			next = id
+		}
+	}
+
+	return graph
+}
+
 // onProxyChains returns all proxy chains
 func (proxyHandler *ProxyHandler) onProxyChains(req message.RequestInterface) message.ReplyInterface {
 	proxyChains := make([]*service.ProxyChain, 0, len(proxyHandler.proxyChains))
@@ -9,6 +9,9 @@ import (
 	"github.com/ahmetson/config-lib/service"
 	"github.com/ahmetson/datatype-lib/data_type/key_value"
 	"github.com/ahmetson/datatype-lib/message"
+	"github.com/ahmetson/dev-lib/dep_handler"
+	"github.com/ahmetson/dev-lib/dep_manager"
+	"github.com/ahmetson/dev-lib/source"
 	handlerConfig "github.com/ahmetson/handler-lib/config"
 	"github.com/ahmetson/handler-lib/manager_client"
 	"github.com/ahmetson/handler-lib/route"
@@ -54,13 +57,24 @@ func (depClient *MockedDepManager) Run(string, string, *clientConfig.Client, str
 	return nil
 }
 
-func (depClient *MockedDepManager) Install(string, string) error {
+func (depClient *MockedDepManager) RunDetached(string, string, *clientConfig.Client, *clientConfig.Client, string) error {
+	if depClient.runFail {
+		return fmt.Errorf("run fail")
+	}
+	return nil
+}
+
+func (depClient *MockedDepManager) Install(string, string, ...*source.Ref) error {
 	if depClient.installFail {
 		return fmt.Errorf("install fail")
 	}
 	return nil
 }
 
+func (depClient *MockedDepManager) Probe(c *clientConfig.Client) (*dep_manager.ProbeResult, error) {
+	return &dep_manager.ProbeResult{Id: c.Id, Reachable: true}, nil
+}
+
 func (depClient *MockedDepManager) Running(*clientConfig.Client) (bool, error) {
 	if depClient.runningFail {
 		return false, fmt.Errorf("running fail")
@@ -74,6 +88,115 @@ func (depClient *MockedDepManager) Installed(string, string) (bool, error) {
 	return depClient.installed, nil
 }
 
+func (depClient *MockedDepManager) SetRestartPolicy(string, *dep_manager.RestartPolicy) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) RestartStatus(id string) (*dep_manager.RestartStatus, error) {
+	return &dep_manager.RestartStatus{Id: id}, nil
+}
+
+func (depClient *MockedDepManager) SetGroup(*dep_manager.Group) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) RemoveGroup(string) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) SetGraph(*dep_manager.Graph) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) Graph() (*dep_manager.ResolvedGraph, error) {
+	return nil, nil
+}
+
+func (depClient *MockedDepManager) RunGraph([]*dep_handler.GraphDep, *clientConfig.Client) error {
+	if depClient.runFail {
+		return fmt.Errorf("run fail")
+	}
+	return nil
+}
+
+func (depClient *MockedDepManager) StopGraph() error {
+	return nil
+}
+
+func (depClient *MockedDepManager) InstallAsync(string, string, ...*source.Ref) (string, error) {
+	if depClient.installFail {
+		return "", fmt.Errorf("install fail")
+	}
+	return "job-1", nil
+}
+
+func (depClient *MockedDepManager) UpdateAsync(string, string, ...*source.Ref) (string, error) {
+	return "job-1", nil
+}
+
+func (depClient *MockedDepManager) RunAsync(string, string, *clientConfig.Client, string) (string, error) {
+	if depClient.runFail {
+		return "", fmt.Errorf("run fail")
+	}
+	return "job-1", nil
+}
+
+func (depClient *MockedDepManager) JobStatus(jobId string) (*dep_handler.Job, error) {
+	return &dep_handler.Job{Id: jobId, State: dep_handler.JobDone}, nil
+}
+
+func (depClient *MockedDepManager) CancelJob(string) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) Stop(string) (*dep_manager.ExitStatus, error) {
+	return &dep_manager.ExitStatus{Stage: dep_manager.StopClosed}, nil
+}
+
+func (depClient *MockedDepManager) List() ([]*dep_manager.DepInfo, error) {
+	return []*dep_manager.DepInfo{}, nil
+}
+
+func (depClient *MockedDepManager) Info(id string) (*dep_manager.DepInfo, error) {
+	return &dep_manager.DepInfo{Id: id}, nil
+}
+
+func (depClient *MockedDepManager) Logs(string, int) ([]string, error) {
+	return []string{}, nil
+}
+
+func (depClient *MockedDepManager) LogsFrom(string, int64) ([]string, int64, error) {
+	return []string{}, 0, nil
+}
+
+func (depClient *MockedDepManager) Update(string, string, ...*source.Ref) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) Rollback(string) (*dep_manager.BinVersion, error) {
+	return &dep_manager.BinVersion{}, nil
+}
+
+func (depClient *MockedDepManager) SetBuildMode(string, dep_manager.BuildMode) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) UpdateLock(string) ([]*dep_manager.LockEntry, error) {
+	return []*dep_manager.LockEntry{}, nil
+}
+
+func (depClient *MockedDepManager) CrashReports(string) ([]*dep_manager.CrashReport, error) {
+	return []*dep_manager.CrashReport{}, nil
+}
+
+func (depClient *MockedDepManager) WaitReady(string, *clientConfig.Client, time.Duration) error {
+	return nil
+}
+
+func (depClient *MockedDepManager) HealthStatus(id string) (*dep_manager.HealthStatus, error) {
+	return &dep_manager.HealthStatus{Id: id, Health: dep_manager.HealthHealthy}, nil
+}
+
 // Define the suite, and absorb the built-in basic suite
 // functionality from testify - including a T() method which
 // returns the current testing orchestra
@@ -540,6 +663,32 @@ func (test *TestProxyHandlerSuite) Test_19_ProxyHandler_onStartProxies() {
 	s().Nil(err)
 }
 
+// Test_20_ProxyGraph tests that the proxy starts after the started proxies it sends the messages to
+func (test *TestProxyHandlerSuite) Test_20_ProxyGraph() {
+	s := test.Require
+
+	proxy3 := &service.Proxy{Id: "id_3", Url: "url_3", Category: "category_3"}
+	proxyChains := []*service.ProxyChain{
+		{Proxies: []*service.Proxy{test.proxy1, test.proxy2}},
+		{Proxies: []*service.Proxy{proxy3, test.proxy1}},
+	}
+	proxies := []*service.Proxy{test.proxy2, test.proxy1}
+
+	resolved, err := proxyGraph(proxyChains, proxies).Resolve()
+	s().NoError(err)
+	s().Equal([]string{test.proxy2.Id, test.proxy1.Id}, resolved.Order)
+	s().Equal([]string{test.proxy2.Id}, resolved.Requires[test.proxy1.Id])
+
+	// the proxy that is not started, e.g. running already, is not required
+	proxyChains = []*service.ProxyChain{
+		{Proxies: []*service.Proxy{proxy3, test.proxy2, test.proxy1}},
+	}
+	resolved, err = proxyGraph(proxyChains, []*service.Proxy{proxy3, test.proxy1}).Resolve()
+	s().NoError(err)
+	s().Equal([]string{test.proxy1.Id, proxy3.Id}, resolved.Order)
+	s().Equal([]string{test.proxy1.Id}, resolved.Requires[proxy3.Id])
+}
+
 func TestProxyHandler(t *testing.T) {
 	suite.Run(t, new(TestProxyHandlerSuite))
 }
@@ -0,0 +1,99 @@
+package context
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Factory creates a new context of the registered type.
+type Factory func() (Interface, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[ContextType]Factory, 1)
+)
+
+func init() {
+	// Register the contexts that are shipped with this module.
+	if err := Register(DevContext, func() (Interface, error) { return NewDev() }); err != nil {
+		panic(err)
+	}
+	if err := Register(TestingContext, func() (Interface, error) { return NewTest(), nil }); err != nil {
+		panic(err)
+	}
+}
+
+// Register the factory of the context type.
+// The registered context types could be created by New or selected by the ContextFlag.
+//
+// Returns an error if the type is empty, reserved or already registered,
+// or if the factory is nil.
+func Register(ctxType ContextType, factory Factory) error {
+	if len(ctxType) == 0 {
+		return fmt.Errorf("empty context type")
+	}
+	if ctxType == UnknownContext {
+		return fmt.Errorf("'%s' context type is reserved", UnknownContext)
+	}
+	if factory == nil {
+		return fmt.Errorf("nil factory for '%s' context type", ctxType)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[ctxType]; ok {
+		return fmt.Errorf("'%s' context type already registered", ctxType)
+	}
+	registry[ctxType] = factory
+
+	return nil
+}
+
+// Registered returns true if the context type has a factory.
+func Registered(ctxType ContextType) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	_, ok := registry[ctxType]
+	return ok
+}
+
+// Types returns the list of registered context types sorted alphabetically.
+func Types() []ContextType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]ContextType, 0, len(registry))
+	for ctxType := range registry {
+		types = append(types, ctxType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// factory returns the factory of the context type.
+// If the type is not registered, then the error lists the available types.
+func factory(ctxType ContextType) (Factory, error) {
+	registryMu.RLock()
+	f, ok := registry[ctxType]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown '%s' context type, registered types: %s", ctxType, strings.Join(Types(), ", "))
+	}
+
+	return f, nil
+}
+
+// unregister removes the factory of the context type.
+// Used by the tests to restore the registry.
+func unregister(ctxType ContextType) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, ctxType)
+}
@@ -0,0 +1,58 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestRegistrySuite struct {
+	suite.Suite
+}
+
+// Test_10_Register tests registering the context types
+func (test *TestRegistrySuite) Test_10_Register() {
+	s := test.Require
+
+	ctxType := ContextType("registry-test")
+	newCtx := func() (Interface, error) { return NewDev() }
+	// the registry is global, so it's restored for the next runs
+	defer unregister(ctxType)
+
+	// the development context is registered by default
+	s().True(Registered(DevContext))
+	s().Contains(Types(), DevContext)
+	s().False(Registered(ctxType))
+
+	// invalid parameters must fail
+	s().Error(Register("", newCtx))
+	s().Error(Register(UnknownContext, newCtx))
+	s().Error(Register(ctxType, nil))
+	s().Error(Register(DevContext, newCtx))
+
+	s().NoError(Register(ctxType, newCtx))
+	s().True(Registered(ctxType))
+	s().Contains(Types(), ctxType)
+
+	// registering twice must fail
+	s().Error(Register(ctxType, newCtx))
+
+	ctx, err := New(ctxType)
+	s().NoError(err)
+	s().Equal(DevContext, ctx.Type())
+
+	// the unknown types must list the registered types
+	_, err = New(UnknownContext)
+	s().Error(err)
+	s().Contains(err.Error(), DevContext)
+	s().Contains(err.Error(), ctxType)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(TestRegistrySuite))
+}
@@ -7,25 +7,136 @@ import (
 	"github.com/ahmetson/os-lib/path"
 	"github.com/asaskevich/govalidator"
 	"net/url"
+	"regexp"
+	"strings"
 )
 
 // The Src struct is used to fetch the source code.
-// It has the optional Branch option.
+// It has the optional Branch, Tag and Commit options.
 // When the Branch is set, then the dependency manager will check out that from remote.
+// When the Tag or Commit is set, then the source code is pinned to it.
 type Src struct {
 	Url      string // Remote Url of the source code
 	GitUrl   string // The Git url derived from the url
 	Branch   string // Branch to fetch. Leave it empty to get the certain branch.
+	Tag      string // Tag to check out, optional. It can't be set along with the Branch.
+	Commit   string // Commit hash or its prefix to check out, optional.
 	localUrl string // Optionally, pass the url to the local directory
 }
 
+// A Ref is the version of the source code to check out.
+// Leave all fields empty to use the default branch head.
+type Ref struct {
+	Branch string `json:"branch,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// commitPattern matches the full or the abbreviated commit hash
+var commitPattern = regexp.MustCompile("^[0-9a-fA-F]{4,40}$")
+
+// versionCommitPattern matches the commit in the url version.
+// The shorter hex strings are treated as the branch names.
+var versionCommitPattern = regexp.MustCompile("^[0-9a-fA-F]{7,40}$")
+
+// semverPattern matches the semantic version with the 'v' prefix, as the Go modules tag it
+var semverPattern = regexp.MustCompile(`^v(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(-(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(\.(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*)?` +
+	`(\+[0-9a-zA-Z-]+(\.[0-9a-zA-Z-]+)*)?$`)
+
+// branchPattern matches the characters allowed in the branch name
+var branchPattern = regexp.MustCompile(`^[0-9a-zA-Z._/-]+$`)
+
+// SplitVersion returns the url without the version, and the version as the ref.
+// The version that starts with 'v' and a digit is the tag, it must be a semantic version.
+// The hex string of 7-40 characters is the commit. Anything else is the branch.
+//
+// If the url has no version, then the ref is empty.
+func SplitVersion(rawUrl string) (string, *Ref, error) {
+	i := strings.LastIndex(rawUrl, "@")
+	if i < 0 {
+		return rawUrl, &Ref{}, nil
+	}
+
+	url, version := rawUrl[:i], rawUrl[i+1:]
+	if len(url) == 0 {
+		return "", nil, fmt.Errorf("no url before the version")
+	}
+	if len(version) == 0 {
+		return "", nil, fmt.Errorf("empty version after '@'")
+	}
+
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		if !semverPattern.MatchString(version) {
+			return "", nil, fmt.Errorf("the '%s' version is not a semantic version like v1.2.3", version)
+		}
+		return url, &Ref{Tag: version}, nil
+	}
+	if versionCommitPattern.MatchString(version) {
+		return url, &Ref{Commit: version}, nil
+	}
+	if !branchPattern.MatchString(version) || strings.Contains(version, "..") ||
+		strings.HasPrefix(version, "-") || strings.HasPrefix(version, "/") || strings.HasSuffix(version, "/") ||
+		strings.HasSuffix(version, ".lock") {
+		return "", nil, fmt.Errorf("the '%s' version is not a valid branch name", version)
+	}
+
+	return url, &Ref{Branch: version}, nil
+}
+
+// IsValid returns an error if both the branch and tag are set, or the commit is not a hash
+func (ref *Ref) IsValid() error {
+	if ref == nil {
+		return fmt.Errorf("nil")
+	}
+	if len(ref.Branch) > 0 && len(ref.Tag) > 0 {
+		return fmt.Errorf("the '%s' branch and '%s' tag are both set", ref.Branch, ref.Tag)
+	}
+	if len(ref.Commit) > 0 && !commitPattern.MatchString(ref.Commit) {
+		return fmt.Errorf("the '%s' commit is not a hash", ref.Commit)
+	}
+
+	return nil
+}
+
+// IsPinned returns true if the ref is the tag or the commit, that doesn't move
+func (ref *Ref) IsPinned() bool {
+	return ref != nil && (len(ref.Tag) > 0 || len(ref.Commit) > 0)
+}
+
+// String returns the ref as it's written in the git: the commit, the tag or the branch
+func (ref *Ref) String() string {
+	if ref == nil {
+		return ""
+	}
+	if len(ref.Commit) > 0 {
+		return ref.Commit
+	}
+	if len(ref.Tag) > 0 {
+		return ref.Tag
+	}
+	return ref.Branch
+}
+
 // New dependency by its source code remote url.
 // It can optionally accept the local url if it's not an empty string.
 //
+// The url may have the version in the Go module style, the Url is kept without it:
+//
+//	github.com/ahmetson/proxy-lib@v0.3.1    // the tag, it must be a semantic version
+//	github.com/ahmetson/proxy-lib@main      // the branch
+//	github.com/ahmetson/proxy-lib@a2ac060   // the commit hash or its prefix
+//
 // It returns error in the following cases:
 //   - url is not a web location that could be turned in to the git.
+//   - the version is not a semantic version, a commit or a branch name.
 //   - localUrl is not a directory with `go.mod` file.
-func New(url string, localUrls ...string) (*Src, error) {
+func New(rawUrl string, localUrls ...string) (*Src, error) {
+	url, ref, err := SplitVersion(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("SplitVersion('%s'): %w", rawUrl, err)
+	}
+
 	gitUrl, err := convertToGitUrl(url)
 	if err != nil {
 		return nil, fmt.Errorf("convertToGitUrl('%s'): %w", url, err)
@@ -36,7 +147,7 @@ func New(url string, localUrls ...string) (*Src, error) {
 		localUrl = localUrls[0]
 	}
 
-	src := &Src{Url: url, GitUrl: gitUrl}
+	src := &Src{Url: url, GitUrl: gitUrl, Branch: ref.Branch, Tag: ref.Tag, Commit: ref.Commit}
 	if len(localUrl) > 0 {
 		if err := src.setLocalUrl(localUrl); err != nil {
 			return nil, fmt.Errorf("src.SetLocalUrl('%s'): %w", localUrl, err)
@@ -84,6 +195,32 @@ func (src *Src) SetBranch(branch string) {
 	src.Branch = branch
 }
 
+// SetRef sets the branch, tag and commit of the repository.
+// Returns an error if the ref is not valid.
+func (src *Src) SetRef(ref *Ref) error {
+	if src == nil {
+		return fmt.Errorf("nil")
+	}
+	if err := ref.IsValid(); err != nil {
+		return fmt.Errorf("ref.IsValid: %w", err)
+	}
+
+	src.Branch = ref.Branch
+	src.Tag = ref.Tag
+	src.Commit = ref.Commit
+
+	return nil
+}
+
+// Ref returns the branch, tag and commit of the repository
+func (src *Src) Ref() *Ref {
+	if src == nil {
+		return &Ref{}
+	}
+
+	return &Ref{Branch: src.Branch, Tag: src.Tag, Commit: src.Commit}
+}
+
 func (src *Src) LocalUrl() string {
 	if src == nil {
 		return ""
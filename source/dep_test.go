@@ -82,6 +82,85 @@ func (test *TestDepSuite) Test_2_SetBranch() {
 	s.Equal(branch, test.src.Branch)
 }
 
+func (test *TestDepSuite) Test_3_SetRef() {
+	s := &test.Suite
+
+	s.Empty(test.src.Ref().String())
+	s.False(test.src.Ref().IsPinned())
+
+	// the branch and tag can't be both set
+	s.Error(test.src.SetRef(&Ref{Branch: "main", Tag: "v1.0.0"}))
+	// the commit must be a hash
+	s.Error(test.src.SetRef(&Ref{Commit: "main"}))
+	s.Error(test.src.SetRef(nil))
+
+	s.NoError(test.src.SetRef(&Ref{Tag: "v1.0.0"}))
+	s.Equal("v1.0.0", test.src.Tag)
+	s.True(test.src.Ref().IsPinned())
+	s.Equal("v1.0.0", test.src.Ref().String())
+
+	// the branch head is pinned by the commit
+	s.NoError(test.src.SetRef(&Ref{Branch: "main", Commit: "a2ac060"}))
+	s.Empty(test.src.Tag)
+	s.Equal("main", test.src.Branch)
+	s.Equal("a2ac060", test.src.Ref().String())
+}
+
+func (test *TestDepSuite) Test_4_SplitVersion() {
+	s := &test.Suite
+
+	url, ref, err := SplitVersion(test.url)
+	s.NoError(err)
+	s.Equal(test.url, url)
+	s.Empty(ref.String())
+
+	// the tag
+	url, ref, err = SplitVersion(test.url + "@v0.3.1")
+	s.NoError(err)
+	s.Equal(test.url, url)
+	s.Equal(&Ref{Tag: "v0.3.1"}, ref)
+
+	_, ref, err = SplitVersion(test.url + "@v1.0.0-rc.1+build.5")
+	s.NoError(err)
+	s.Equal("v1.0.0-rc.1+build.5", ref.Tag)
+
+	// the tag must be a semantic version
+	_, _, err = SplitVersion(test.url + "@v1.2")
+	s.Error(err)
+	_, _, err = SplitVersion(test.url + "@v01.2.3")
+	s.Error(err)
+
+	// the commit
+	_, ref, err = SplitVersion(test.url + "@a2ac060")
+	s.NoError(err)
+	s.Equal(&Ref{Commit: "a2ac060"}, ref)
+
+	// the branch, including the short hex names
+	_, ref, err = SplitVersion(test.url + "@feature/server")
+	s.NoError(err)
+	s.Equal(&Ref{Branch: "feature/server"}, ref)
+	_, ref, err = SplitVersion(test.url + "@cafe")
+	s.NoError(err)
+	s.Equal("cafe", ref.Branch)
+
+	// invalid branch names
+	_, _, err = SplitVersion(test.url + "@")
+	s.Error(err)
+	_, _, err = SplitVersion(test.url + "@main..dev")
+	s.Error(err)
+	_, _, err = SplitVersion(test.url + "@with space")
+	s.Error(err)
+	_, _, err = SplitVersion("@main")
+	s.Error(err)
+
+	// the url is kept without the version
+	src, err := New(test.url + "@v0.3.1")
+	s.NoError(err)
+	s.Equal(test.url, src.Url)
+	s.Equal("https://github.com/ahmetson/test-manager.git", src.GitUrl)
+	s.Equal("v0.3.1", src.Tag)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestDep(t *testing.T) {
@@ -0,0 +1,166 @@
+package context
+
+import (
+	"fmt"
+	configClient "github.com/ahmetson/config-lib/client"
+	"github.com/ahmetson/dev-lib/dep_client"
+	"github.com/ahmetson/dev-lib/proxy_client"
+)
+
+// A TestContext keeps the dependencies and proxies in the memory.
+// It doesn't start the config engine, the dependency handler or the proxy handler.
+// Neither it builds nor spawns any dependency.
+//
+// The dep client and proxy client are fakes that record the calls.
+// Use TestContext.FakeDepClient and TestContext.FakeProxyClient to script their outcomes.
+type TestContext struct {
+	configClient        configClient.Interface
+	depClient           dep_client.Interface
+	proxyClient         proxy_client.Interface
+	configStarted       bool
+	depManagerStarted   bool
+	proxyHandlerStarted bool
+	serviceId           string
+	serviceUrl          string
+}
+
+// NewTest creates the testing context.
+// The config engine is not set. Use TestContext.SetConfig if the config is needed.
+func NewTest() *TestContext {
+	return &TestContext{}
+}
+
+func (ctx *TestContext) IsRunning() bool {
+	return ctx.configStarted && ctx.depManagerStarted && ctx.proxyHandlerStarted
+}
+
+func (ctx *TestContext) IsConfigRunning() bool {
+	return ctx.configStarted
+}
+
+func (ctx *TestContext) IsDepManagerRunning() bool {
+	return ctx.depManagerStarted
+}
+
+func (ctx *TestContext) IsProxyHandlerRunning() bool {
+	return ctx.proxyHandlerStarted
+}
+
+// SetDepClient sets the dep client. Any implementation is accepted.
+func (ctx *TestContext) SetDepClient(dc dep_client.Interface) error {
+	if dc == nil {
+		return fmt.Errorf("nil dep client")
+	}
+	ctx.depClient = dc
+
+	return nil
+}
+
+func (ctx *TestContext) DepClient() dep_client.Interface {
+	return ctx.depClient
+}
+
+// FakeDepClient returns the fake dep client set by TestContext.StartDepManager.
+// Returns nil if the dep manager is not started, or a custom dep client was set.
+func (ctx *TestContext) FakeDepClient() *dep_client.Fake {
+	fake, _ := ctx.depClient.(*dep_client.Fake)
+	return fake
+}
+
+// SetConfig sets the config engine. It could be a fake as well.
+func (ctx *TestContext) SetConfig(socket configClient.Interface) {
+	ctx.configClient = socket
+}
+
+// Config returns the config engine in the context. It's nil unless it was set by TestContext.SetConfig.
+func (ctx *TestContext) Config() configClient.Interface {
+	return ctx.configClient
+}
+
+// SetProxyClient sets the proxy client. Any implementation is accepted.
+func (ctx *TestContext) SetProxyClient(proxyClient proxy_client.Interface) error {
+	if proxyClient == nil {
+		return fmt.Errorf("nil proxy client")
+	}
+	ctx.proxyClient = proxyClient
+
+	return nil
+}
+
+func (ctx *TestContext) ProxyClient() proxy_client.Interface {
+	return ctx.proxyClient
+}
+
+// FakeProxyClient returns the fake proxy client set by TestContext.StartProxyHandler.
+// Returns nil if the proxy handler is not started, or a custom proxy client was set.
+func (ctx *TestContext) FakeProxyClient() *proxy_client.Fake {
+	fake, _ := ctx.proxyClient.(*proxy_client.Fake)
+	return fake
+}
+
+// Type returns TestingContext
+func (ctx *TestContext) Type() ContextType {
+	return TestingContext
+}
+
+// SetService sets the service id and url for which this context belongs too.
+func (ctx *TestContext) SetService(id string, url string) {
+	ctx.serviceId = id
+	ctx.serviceUrl = url
+}
+
+// StartConfig marks the config engine as started. No engine is started.
+func (ctx *TestContext) StartConfig() error {
+	if ctx.configStarted {
+		return fmt.Errorf("config engine already started")
+	}
+	ctx.configStarted = true
+
+	return nil
+}
+
+// StartDepManager sets the fake dep client unless the dep client was set already.
+func (ctx *TestContext) StartDepManager() error {
+	if !ctx.configStarted {
+		return fmt.Errorf("config engine not started. call StartConfig first")
+	}
+	if ctx.depManagerStarted {
+		return fmt.Errorf("dep manager already started")
+	}
+
+	if ctx.depClient == nil {
+		ctx.depClient = dep_client.NewFake()
+	}
+	ctx.depManagerStarted = true
+
+	return nil
+}
+
+// StartProxyHandler sets the fake proxy client unless the proxy client was set already.
+func (ctx *TestContext) StartProxyHandler() error {
+	if len(ctx.serviceId) == 0 || len(ctx.serviceUrl) == 0 {
+		return fmt.Errorf("service parameters are not set. call TestContext.SetService first")
+	}
+	if !ctx.configStarted {
+		return fmt.Errorf("config engine not started. call StartConfig first")
+	}
+	if ctx.proxyHandlerStarted {
+		return fmt.Errorf("proxy handler already started")
+	}
+
+	if ctx.proxyClient == nil {
+		ctx.proxyClient = proxy_client.NewFake()
+	}
+	ctx.proxyHandlerStarted = true
+
+	return nil
+}
+
+// Close marks everything as stopped. The clients are kept to inspect the calls after closing.
+func (ctx *TestContext) Close() error {
+	ctx.proxyHandlerStarted = false
+	ctx.depManagerStarted = false
+	ctx.configStarted = false
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package context
+
+import (
+	"fmt"
+	clientConfig "github.com/ahmetson/client-lib/config"
+	"github.com/ahmetson/dev-lib/dep_client"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Define the suite, and absorb the built-in basic suite
+// functionality from testify - including a T() method which
+// returns the current testing orchestra
+type TestTestCtxSuite struct {
+	suite.Suite
+}
+
+// Test_10_New tests creation of the test context by the registered type
+func (test *TestTestCtxSuite) Test_10_New() {
+	s := test.Require
+
+	ctx, err := New(TestingContext)
+	s().NoError(err)
+	s().Equal(TestingContext, ctx.Type())
+	s().False(ctx.IsRunning())
+
+	// the dep manager requires the config
+	s().Error(ctx.StartDepManager())
+	s().NoError(ctx.StartConfig())
+	s().Error(ctx.StartConfig())
+	s().NoError(ctx.StartDepManager())
+	s().Error(ctx.StartDepManager())
+
+	// the proxy handler requires the service
+	s().Error(ctx.StartProxyHandler())
+	ctx.SetService("test", "github.com/ahmetson/test")
+	s().NoError(ctx.StartProxyHandler())
+	s().True(ctx.IsRunning())
+
+	s().NotNil(ctx.DepClient())
+	s().NotNil(ctx.ProxyClient())
+
+	s().NoError(ctx.Close())
+	s().False(ctx.IsRunning())
+}
+
+// Test_11_FakeDepClient tests that dep client calls are recorded and scripted
+func (test *TestTestCtxSuite) Test_11_FakeDepClient() {
+	s := test.Require
+
+	ctx := NewTest()
+	s().NoError(ctx.StartConfig())
+	s().NoError(ctx.StartDepManager())
+	fake := ctx.FakeDepClient()
+	s().NotNil(fake)
+
+	url := "github.com/ahmetson/test-manager"
+	depClient := &clientConfig.Client{Id: "test-manager"}
+
+	installed, err := ctx.DepClient().Installed(url, "")
+	s().NoError(err)
+	s().False(installed)
+
+	s().NoError(ctx.DepClient().Install(url, ""))
+	installed, err = ctx.DepClient().Installed(url, "")
+	s().NoError(err)
+	s().True(installed)
+
+	s().NoError(ctx.DepClient().Run(url, depClient.Id, nil, ""))
+	running, err := ctx.DepClient().Running(depClient)
+	s().NoError(err)
+	s().True(running)
+
+	s().NoError(ctx.DepClient().CloseDep(depClient))
+	running, err = ctx.DepClient().Running(depClient)
+	s().NoError(err)
+	s().False(running)
+
+	// scripted failure
+	fake.Fail(dep_client.RunMethod, fmt.Errorf("scripted"))
+	s().Error(ctx.DepClient().Run(url, depClient.Id, nil, ""))
+	fake.Fail(dep_client.RunMethod, nil)
+	s().NoError(ctx.DepClient().Run(url, depClient.Id, nil, ""))
+
+	s().Len(fake.Calls(dep_client.RunMethod), 2)
+	s().Len(fake.Calls(dep_client.InstallMethod), 1)
+	s().Len(fake.Calls(), 9)
+}
+
+// In order for 'go test' to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run
+func TestTestCtx(t *testing.T) {
+	suite.Run(t, new(TestTestCtxSuite))
+}
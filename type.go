@@ -6,6 +6,8 @@ type ContextType = string
 const (
 	// DevContext indicates that all dependency proxies are in the local machine
 	DevContext ContextType = "development"
+	// TestingContext indicates that all dependencies are faked in the memory
+	TestingContext ContextType = "test"
 	// UnknownContext indicates that the context is unspecified.
 	UnknownContext ContextType = "unknown"
 